@@ -1,5 +1,11 @@
 package gophercloud
 
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
 /*
 AuthOptions stores information needed to authenticate to an OpenStack cluster.
 You can populate one manually, or use a provider's AuthOptionsFromEnv() function
@@ -17,14 +23,24 @@ type AuthOptions struct {
 	// function.
 	IdentityEndpoint string
 
+	// IdentityEndpointFallbacks [optional] lists further URLs of the same
+	// identity service, such as a second load balancer, to try in order when
+	// IdentityEndpoint can't be reached. Only connection failures move on to the
+	// next URL; if the identity service rejects the credentials, that error is
+	// returned immediately.
+	IdentityEndpointFallbacks []string
+
 	// Username is required if using Identity V2 API. Consult with your provider's
 	// control panel to discover your account's username. In Identity V3, either
 	// UserID or a combination of Username and DomainID or DomainName are needed.
 	Username, UserID string
 
-	// Exactly one of Password or APIKey is required for the Identity V2 and V3
-	// APIs. Consult with your provider's control panel to discover your account's
-	// preferred method of authentication.
+	// A Password is required for the Identity V2 and V3 APIs unless a TokenID
+	// is provided. An APIKey is only honored by the Rackspace provider, which
+	// sends it as RAX-KSKEY:apiKeyCredentials and prefers it to a Password if
+	// both are set; the OpenStack V2 and V3 tokens packages reject it with
+	// ErrAPIKeyProvided. Consult with your provider's control panel to discover
+	// your account's preferred method of authentication.
 	Password, APIKey string
 
 	// At most one of DomainID and DomainName must be provided if using Username
@@ -45,6 +61,59 @@ type AuthOptions struct {
 	AllowReauth bool
 
 	// TokenID allows users to authenticate (possibly as another user) with an
-	// authentication token ID.
+	// authentication token ID, such as one obtained out-of-band by another
+	// process. With the Identity V2 API, a TokenID takes precedence over a
+	// Username and Password if both are provided.
 	TokenID string
+
+	// IdentityVersion forces authentication against a particular version of the
+	// identity service, "2.0" or "3", rather than the most recent one that the
+	// IdentityEndpoint advertises. Leave it blank to negotiate a version.
+	IdentityVersion string
+
+	// TrustID scopes the token to a trust, so that a trustee may act on behalf
+	// of the trustor within the bounds the trust allows. The trust determines
+	// the tenant, so it can't be combined with TenantID or TenantName. It's
+	// currently only honored by the Identity V2 API.
+	TrustID string
+
+	// ExtraHeaders are sent along with the authentication request, for gateways
+	// that route requests on headers such as tracing or tenant-routing ones
+	// before inspecting the body. Headers that gophercloud sets itself, like
+	// Content-Type, Accept and X-Auth-Token, can't be overridden. They're
+	// currently only honored by the Identity V2 API.
+	ExtraHeaders http.Header
+
+	// SuggestTenants asks for a more helpful error when authentication scoped by
+	// TenantName is rejected. Gophercloud then authenticates again without a
+	// tenant and lists the tenants the user can access, so that the error can
+	// name the valid ones. It costs two more requests, and only on failure. It's
+	// currently only honored by the Identity V2 API.
+	SuggestTenants bool
+}
+
+// String renders the AuthOptions for logging with the Password, APIKey and TokenID redacted. A
+// redacted value is shown as "***", so it's still apparent whether one was provided. Only the names
+// of the ExtraHeaders are shown, since their values may carry credentials too.
+func (opts AuthOptions) String() string {
+	redact := func(secret string) string {
+		if secret == "" {
+			return ""
+		}
+		return "***"
+	}
+
+	headers := make([]string, 0, len(opts.ExtraHeaders))
+	for name := range opts.ExtraHeaders {
+		headers = append(headers, name)
+	}
+	sort.Strings(headers)
+
+	return fmt.Sprintf(
+		"{IdentityEndpoint:%q IdentityEndpointFallbacks:%q Username:%q UserID:%q Password:%q APIKey:%q DomainID:%q DomainName:%q "+
+			"TenantID:%q TenantName:%q AllowReauth:%t TokenID:%q IdentityVersion:%q TrustID:%q ExtraHeaders:%q SuggestTenants:%t}",
+		opts.IdentityEndpoint, opts.IdentityEndpointFallbacks, opts.Username, opts.UserID, redact(opts.Password), redact(opts.APIKey),
+		opts.DomainID, opts.DomainName, opts.TenantID, opts.TenantName, opts.AllowReauth,
+		redact(opts.TokenID), opts.IdentityVersion, opts.TrustID, headers, opts.SuggestTenants,
+	)
 }
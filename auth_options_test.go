@@ -0,0 +1,43 @@
+package gophercloud
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestAuthOptionsString(t *testing.T) {
+	opts := AuthOptions{
+		IdentityEndpoint:          "https://identity.example.com/v2.0/",
+		IdentityEndpointFallbacks: []string{"https://identity2.example.com/v2.0/"},
+		Username:                  "me",
+		Password:                  "swordfish",
+		TenantName:                "demo",
+		ExtraHeaders:              http.Header{"X-Trace-Id": {"abc"}, "X-Route": {"east"}},
+		SuggestTenants:            true,
+	}
+
+	expected := `{IdentityEndpoint:"https://identity.example.com/v2.0/" ` +
+		`IdentityEndpointFallbacks:["https://identity2.example.com/v2.0/"] Username:"me" UserID:"" Password:"***" ` +
+		`APIKey:"" DomainID:"" DomainName:"" TenantID:"" TenantName:"demo" AllowReauth:false TokenID:"" ` +
+		`IdentityVersion:"" TrustID:"" ExtraHeaders:["X-Route" "X-Trace-Id"] SuggestTenants:true}`
+	th.CheckEquals(t, expected, opts.String())
+	th.CheckEquals(t, expected, fmt.Sprintf("%v", opts))
+	th.CheckEquals(t, expected, fmt.Sprintf("%+v", opts))
+}
+
+func TestAuthOptionsStringRedactsAllSecrets(t *testing.T) {
+	opts := AuthOptions{Password: "swordfish", APIKey: "0123456789abcdef", TokenID: "aaaabbbbccccdddd"}
+	rendered := opts.String()
+
+	for _, secret := range []string{"swordfish", "0123456789abcdef", "aaaabbbbccccdddd"} {
+		if strings.Contains(rendered, secret) {
+			t.Errorf("Secret %q leaked into %s", secret, rendered)
+		}
+	}
+	th.CheckEquals(t, true, strings.Contains(rendered, `APIKey:"***"`))
+	th.CheckEquals(t, true, strings.Contains(rendered, `TokenID:"***"`))
+}
@@ -0,0 +1,143 @@
+package gophercloud
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerThreshold is the number of consecutive failures after which an EndpointBreaker
+	// that doesn't specify Threshold deprioritizes an endpoint.
+	DefaultBreakerThreshold = 3
+
+	// DefaultBreakerCooldown is how long an EndpointBreaker that doesn't specify Cooldown keeps an
+	// endpoint deprioritized.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// EndpointBreaker remembers which endpoint URLs have recently been failing, so that endpoint
+// selection can prefer their healthy alternatives. Once an endpoint has failed Threshold times in a
+// row it's tripped: for the next Cooldown, it's ordered after every healthy endpoint. When the
+// cooldown elapses it's tried again, and a single further failure trips it anew, while a success
+// clears its record.
+//
+// Report the outcome of each request with RecordFailure and RecordSuccess, and pass the breaker to
+// endpoint selection through EndpointOpts.Breaker. The zero value uses the defaults declared above
+// and is safe for concurrent use. Every method may also be called on a nil *EndpointBreaker, which
+// records nothing and never trips, so an optional EndpointOpts.Breaker can be used without checking.
+type EndpointBreaker struct {
+	// Threshold is the number of consecutive failures that trips an endpoint.
+	Threshold int
+
+	// Cooldown is how long a tripped endpoint stays deprioritized.
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures map[string]int
+	tripped  map[string]time.Time
+
+	// now reports the current time. Tests replace it.
+	now func() time.Time
+}
+
+// RecordFailure notes that a request to the endpoint at url failed.
+func (b *EndpointBreaker) RecordFailure(url string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures == nil {
+		b.failures = make(map[string]int)
+		b.tripped = make(map[string]time.Time)
+	}
+
+	b.failures[url]++
+	if b.failures[url] >= b.threshold() {
+		cooldown := b.Cooldown
+		if cooldown <= 0 {
+			cooldown = DefaultBreakerCooldown
+		}
+		b.tripped[url] = b.clock().Add(cooldown)
+	}
+}
+
+// RecordSuccess notes that a request to the endpoint at url succeeded, clearing its failures.
+func (b *EndpointBreaker) RecordSuccess(url string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.failures, url)
+	delete(b.tripped, url)
+}
+
+// IsTripped reports whether the endpoint at url is currently deprioritized.
+func (b *EndpointBreaker) IsTripped(url string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.isTripped(url)
+}
+
+// Prioritize returns urls reordered so that healthy endpoints come before tripped ones. The relative
+// order within each group is preserved. A nil breaker returns urls unchanged.
+func (b *EndpointBreaker) Prioritize(urls []string) []string {
+	if b == nil {
+		return urls
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	healthy := make([]string, 0, len(urls))
+	var tripped []string
+	for _, url := range urls {
+		if b.isTripped(url) {
+			tripped = append(tripped, url)
+		} else {
+			healthy = append(healthy, url)
+		}
+	}
+	return append(healthy, tripped...)
+}
+
+// Reset forgets every recorded failure.
+func (b *EndpointBreaker) Reset() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = nil
+	b.tripped = nil
+}
+
+func (b *EndpointBreaker) isTripped(url string) bool {
+	until, ok := b.tripped[url]
+	return ok && b.clock().Before(until)
+}
+
+func (b *EndpointBreaker) threshold() int {
+	if b.Threshold <= 0 {
+		return DefaultBreakerThreshold
+	}
+	return b.Threshold
+}
+
+func (b *EndpointBreaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
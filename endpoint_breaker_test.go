@@ -0,0 +1,55 @@
+package gophercloud
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestEndpointBreakerTrips(t *testing.T) {
+	now := time.Date(2015, time.March, 1, 12, 0, 0, 0, time.UTC)
+	b := &EndpointBreaker{Threshold: 2, Cooldown: time.Minute, now: func() time.Time { return now }}
+
+	b.RecordFailure("https://a/")
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+
+	b.RecordFailure("https://a/")
+	th.CheckEquals(t, true, b.IsTripped("https://a/"))
+	th.CheckDeepEquals(t, []string{"https://b/", "https://a/"}, b.Prioritize([]string{"https://a/", "https://b/"}))
+
+	// Once the cooldown elapses the endpoint is tried again, but one more failure trips it anew.
+	now = now.Add(time.Minute)
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+	b.RecordFailure("https://a/")
+	th.CheckEquals(t, true, b.IsTripped("https://a/"))
+
+	b.RecordSuccess("https://a/")
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+	b.RecordFailure("https://a/")
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+}
+
+func TestEndpointBreakerDefaultsAndReset(t *testing.T) {
+	b := &EndpointBreaker{}
+	for i := 0; i < DefaultBreakerThreshold; i++ {
+		th.CheckEquals(t, false, b.IsTripped("https://a/"))
+		b.RecordFailure("https://a/")
+	}
+	th.CheckEquals(t, true, b.IsTripped("https://a/"))
+
+	b.Reset()
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+	th.CheckDeepEquals(t, []string{"https://a/", "https://b/"}, b.Prioritize([]string{"https://a/", "https://b/"}))
+}
+
+func TestNilEndpointBreaker(t *testing.T) {
+	var b *EndpointBreaker
+	for i := 0; i < DefaultBreakerThreshold; i++ {
+		b.RecordFailure("https://a/")
+	}
+	th.CheckEquals(t, false, b.IsTripped("https://a/"))
+	th.CheckDeepEquals(t, []string{"https://a/", "https://b/"}, b.Prioritize([]string{"https://a/", "https://b/"}))
+	b.RecordSuccess("https://a/")
+	b.Reset()
+}
@@ -1,6 +1,10 @@
 package gophercloud
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var (
 	// ErrServiceNotFound is returned when no service in a service catalog matches
@@ -10,12 +14,68 @@ var (
 	ErrServiceNotFound = errors.New("No suitable service could be found in the service catalog.")
 
 	// ErrEndpointNotFound is returned when no available endpoints match the
-	// provided EndpointOpts. This is also generally returned by provider service
-	// factory methods, and usually indicates that a region was specified
-	// incorrectly.
+	// provided EndpointOpts. The OpenStack catalog lookups return an
+	// *EndpointNotFoundError instead, which errors.Is still reports as
+	// ErrEndpointNotFound, but other EndpointLocators may return it directly.
 	ErrEndpointNotFound = errors.New("No suitable endpoint could be found in the service catalog.")
+
+	// ErrServiceNotInCatalog is returned when the service catalog doesn't have
+	// an entry of the requested Type at all. It usually indicates that the
+	// service isn't deployed by the provider, or that the Type is misspelled.
+	ErrServiceNotInCatalog = errors.New("The service catalog doesn't contain any service of the requested type.")
+
+	// ErrNoEndpointForCriteria is returned when the service catalog has an entry
+	// of the requested Type, but none of its endpoints match the other
+	// EndpointOpts, such as the Region, Name or Availability. It usually
+	// indicates that a region was specified incorrectly.
+	ErrNoEndpointForCriteria = errors.New("The service catalog contains the requested service type, but none of its endpoints match the requested region, name or availability.")
 )
 
+// EndpointNotFoundError is returned when no endpoint in a service catalog
+// matches the provided EndpointOpts. It records the criteria that were searched
+// for, and wraps the reason, usually ErrServiceNotInCatalog or
+// ErrNoEndpointForCriteria. errors.Is reports it as ErrEndpointNotFound as well
+// as its reason.
+type EndpointNotFoundError struct {
+	Opts EndpointOpts
+	Err  error
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *EndpointNotFoundError) Error() string {
+	var criteria []string
+	for _, c := range []struct{ name, value string }{
+		{"type", e.Opts.Type},
+		{"name", e.Opts.Name},
+		{"name-prefix", e.Opts.NamePrefix},
+		{"region", e.Opts.Region},
+		{"availability", string(e.Opts.Availability)},
+		{"version", e.Opts.VersionID},
+		{"tenant", e.Opts.TenantID},
+	} {
+		if c.value != "" {
+			criteria = append(criteria, c.name+"="+c.value)
+		}
+	}
+
+	msg := "No endpoint found for " + strings.Join(criteria, " ")
+	if e.Err != nil {
+		return msg + ". " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the reason no endpoint was found.
+func (e *EndpointNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrEndpointNotFound, so that callers checking
+// for the sentinel keep working.
+func (e *EndpointNotFoundError) Is(target error) bool {
+	return target == ErrEndpointNotFound
+}
+
 // Availability indicates to whom a specific service endpoint is accessible:
 // the internet at large, internal networks only, or only to administrators.
 // Different identity services use different terminology for these. Identity v2
@@ -38,6 +98,27 @@ const (
 	AvailabilityInternal Availability = "internal"
 )
 
+// IsValid reports whether an Availability is one of AvailabilityPublic,
+// AvailabilityInternal, or AvailabilityAdmin.
+func (a Availability) IsValid() bool {
+	switch a {
+	case AvailabilityPublic, AvailabilityInternal, AvailabilityAdmin:
+		return true
+	}
+	return false
+}
+
+// ParseAvailability converts a string, usually read from configuration or a
+// command-line flag, into an Availability. The accepted spellings are "public",
+// "internal", and "admin", in any combination of upper and lower case.
+func ParseAvailability(s string) (Availability, error) {
+	a := Availability(strings.ToLower(s))
+	if !a.IsValid() {
+		return "", fmt.Errorf("Unrecognized availability %q: expected one of public, internal, or admin", s)
+	}
+	return a, nil
+}
+
 // EndpointOpts specifies search criteria used by queries against an
 // OpenStack service catalog. The options must contain enough information to
 // unambiguously identify one, and only one, endpoint within the catalog.
@@ -55,6 +136,12 @@ type EndpointOpts struct {
 	// different Name, which is why both Type and Name are sometimes needed.
 	Name string
 
+	// NamePrefix [optional] matches services whose Name begins with it, for
+	// providers that name the same service differently across regions (e.g.,
+	// "cloudServers" and "cloudServersOpenStack"). It may be combined with Name,
+	// in which case both must match.
+	NamePrefix string
+
 	// Region [required] is the geographic region in which the endpoint resides,
 	// generally specifying which datacenter should house your resources.
 	// Required only for services that span multiple regions.
@@ -67,6 +154,83 @@ type EndpointOpts struct {
 	// Availability is not required, and defaults to AvailabilityPublic. Not all
 	// providers or services offer all Availability options.
 	Availability Availability
+
+	// AvailabilityFallbacks [optional] lists the availabilities to try, in
+	// order, when the matching endpoint doesn't offer the requested
+	// Availability. For example, many clouds only publish public URLs, so
+	// []Availability{AvailabilityPublic} lets a request for an internal
+	// endpoint degrade gracefully. Without fallbacks, a missing URL is an error.
+	AvailabilityFallbacks []Availability
+
+	// VersionID [optional] is the API version of the endpoint to be returned,
+	// as advertised by the "versionId" attribute of an Identity v2 catalog
+	// endpoint. Use it to choose between several versions of the same service.
+	// Identity v3 catalogs don't carry version information, so it's ignored
+	// there.
+	VersionID string
+
+	// TenantID [optional] is the tenant that the endpoint to be returned is
+	// scoped to, as advertised by the "tenantId" attribute of an Identity v2
+	// catalog endpoint. Some providers publish one endpoint per tenant for the
+	// same service. Identity v3 catalogs don't carry tenant information, so it's
+	// ignored there.
+	TenantID string
+
+	// DeduplicateURLs [optional] treats endpoints whose URLs for the requested
+	// Availability are identical, once normalized, as a single endpoint. Set it
+	// for catalogs that list the same endpoint more than once, for example under
+	// two entries, which would otherwise be reported as ambiguous.
+	DeduplicateURLs bool
+
+	// CaseInsensitive [optional] compares Type, Name and NamePrefix with
+	// catalog entries regardless of case, for providers that advertise
+	// "Compute" where OpenStack standardizes "compute". By default they must
+	// match exactly.
+	CaseInsensitive bool
+
+	// Breaker [optional] tracks endpoints that have recently been failing.
+	// When several endpoints match, the URLs of those it has tripped are
+	// listed after the healthy ones. It doesn't affect lookups that must
+	// resolve to a single endpoint.
+	Breaker *EndpointBreaker
+}
+
+// MatchType reports whether a service of the given type satisfies the Type
+// criterion.
+func (eo EndpointOpts) MatchType(t string) bool {
+	if eo.CaseInsensitive {
+		return strings.EqualFold(t, eo.Type)
+	}
+	return t == eo.Type
+}
+
+// MatchName reports whether a service with the given name satisfies the Name
+// and NamePrefix criteria, either of which may be left blank to match any name.
+func (eo EndpointOpts) MatchName(name string) bool {
+	if eo.CaseInsensitive {
+		return (eo.Name == "" || strings.EqualFold(name, eo.Name)) &&
+			strings.HasPrefix(strings.ToLower(name), strings.ToLower(eo.NamePrefix))
+	}
+	return (eo.Name == "" || name == eo.Name) && strings.HasPrefix(name, eo.NamePrefix)
+}
+
+// WithType returns a copy of the EndpointOpts with its Type replaced, leaving the receiver as it is.
+// Use it to resolve several services from one base set of options.
+func (eo EndpointOpts) WithType(t string) EndpointOpts {
+	eo.Type = t
+	return eo
+}
+
+// WithRegion returns a copy of the EndpointOpts with its Region replaced.
+func (eo EndpointOpts) WithRegion(region string) EndpointOpts {
+	eo.Region = region
+	return eo
+}
+
+// WithAvailability returns a copy of the EndpointOpts with its Availability replaced.
+func (eo EndpointOpts) WithAvailability(a Availability) EndpointOpts {
+	eo.Availability = a
+	return eo
 }
 
 /*
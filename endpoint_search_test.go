@@ -1,6 +1,7 @@
 package gophercloud
 
 import (
+	"errors"
 	"testing"
 
 	th "github.com/rackspace/gophercloud/testhelper"
@@ -17,3 +18,65 @@ func TestApplyDefaultsToEndpointOpts(t *testing.T) {
 	expected = EndpointOpts{Availability: AvailabilityPublic, Type: "compute"}
 	th.CheckDeepEquals(t, expected, eo)
 }
+
+func TestAvailabilityIsValid(t *testing.T) {
+	th.CheckEquals(t, true, AvailabilityPublic.IsValid())
+	th.CheckEquals(t, true, AvailabilityInternal.IsValid())
+	th.CheckEquals(t, true, AvailabilityAdmin.IsValid())
+	th.CheckEquals(t, false, Availability("").IsValid())
+	th.CheckEquals(t, false, Availability("Public").IsValid())
+	th.CheckEquals(t, false, Availability("publicURL").IsValid())
+}
+
+func TestParseAvailability(t *testing.T) {
+	for input, expected := range map[string]Availability{
+		"public":   AvailabilityPublic,
+		"Internal": AvailabilityInternal,
+		"ADMIN":    AvailabilityAdmin,
+	} {
+		actual, err := ParseAvailability(input)
+		th.AssertNoErr(t, err)
+		th.CheckEquals(t, expected, actual)
+	}
+
+	_, err := ParseAvailability("pubilc")
+	th.CheckEquals(t, `Unrecognized availability "pubilc": expected one of public, internal, or admin`, err.Error())
+}
+
+func TestEndpointOptsMatchName(t *testing.T) {
+	th.CheckEquals(t, true, EndpointOpts{}.MatchName("nova"))
+	th.CheckEquals(t, true, EndpointOpts{Name: "nova"}.MatchName("nova"))
+	th.CheckEquals(t, false, EndpointOpts{Name: "nova"}.MatchName("novaNext"))
+	th.CheckEquals(t, true, EndpointOpts{NamePrefix: "cloudServers"}.MatchName("cloudServersOpenStack"))
+	th.CheckEquals(t, false, EndpointOpts{NamePrefix: "cloudServers"}.MatchName("nova"))
+	th.CheckEquals(t, false, EndpointOpts{Name: "cloudServers", NamePrefix: "cloudServers"}.MatchName("cloudServersOpenStack"))
+}
+
+func TestEndpointOptsCaseInsensitive(t *testing.T) {
+	th.CheckEquals(t, false, EndpointOpts{Type: "compute"}.MatchType("Compute"))
+	th.CheckEquals(t, true, EndpointOpts{Type: "compute", CaseInsensitive: true}.MatchType("Compute"))
+	th.CheckEquals(t, false, EndpointOpts{Type: "compute", CaseInsensitive: true}.MatchType("volume"))
+	th.CheckEquals(t, false, EndpointOpts{Name: "nova"}.MatchName("Nova"))
+	th.CheckEquals(t, true, EndpointOpts{Name: "nova", CaseInsensitive: true}.MatchName("Nova"))
+	th.CheckEquals(t, true, EndpointOpts{NamePrefix: "cloudservers", CaseInsensitive: true}.MatchName("cloudServersOpenStack"))
+}
+
+func TestEndpointOptsWith(t *testing.T) {
+	base := EndpointOpts{Type: "compute", Region: "RegionOne", Availability: AvailabilityPublic}
+
+	th.CheckDeepEquals(t, EndpointOpts{Type: "volume", Region: "RegionOne", Availability: AvailabilityPublic}, base.WithType("volume"))
+	th.CheckDeepEquals(t, EndpointOpts{Type: "compute", Region: "RegionTwo", Availability: AvailabilityPublic}, base.WithRegion("RegionTwo"))
+	th.CheckDeepEquals(t, EndpointOpts{Type: "compute", Region: "RegionOne", Availability: AvailabilityInternal}, base.WithAvailability(AvailabilityInternal))
+	th.CheckDeepEquals(t, EndpointOpts{Type: "compute", Region: "RegionOne", Availability: AvailabilityPublic}, base)
+}
+
+func TestEndpointNotFoundError(t *testing.T) {
+	err := &EndpointNotFoundError{
+		Opts: EndpointOpts{Type: "compute", Region: "RegionOne", Availability: AvailabilityPublic},
+		Err:  ErrNoEndpointForCriteria,
+	}
+	th.CheckEquals(t, "No endpoint found for type=compute region=RegionOne availability=public. "+ErrNoEndpointForCriteria.Error(), err.Error())
+	th.CheckEquals(t, true, errors.Is(err, ErrEndpointNotFound))
+	th.CheckEquals(t, true, errors.Is(err, ErrNoEndpointForCriteria))
+	th.CheckEquals(t, false, errors.Is(err, ErrServiceNotInCatalog))
+}
@@ -0,0 +1,185 @@
+package gophercloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DebugEnv is the environment variable that turns on request logging for ProviderClients that
+// don't have a Logger set. Any non-empty value logs requests and responses to stderr; the value
+// "bodies" logs their JSON bodies as well.
+const DebugEnv = "GOPHERCLOUD_DEBUG"
+
+// Logger receives diagnostic messages describing each HTTP request issued by a ProviderClient. The
+// standard library's *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// redacted replaces sensitive values in logged headers and bodies.
+const redacted = "***"
+
+// redactedHeaders lists the headers whose values are never logged.
+var redactedHeaders = []string{"X-Auth-Token", "X-Subject-Token", "X-Auth-Key"}
+
+// redactedFields lists the JSON object keys whose values are never logged.
+var redactedFields = map[string]bool{"password": true, "apiKey": true}
+
+// redactedQueryParams lists the query parameters whose values are never logged. belongsTo
+// accompanies a token being validated, so it's hidden along with it.
+var redactedQueryParams = map[string]bool{"token": true, "tokenId": true, "token_id": true, "belongsTo": true}
+
+// SetLogger directs the client to describe every request it issues, and the response to it, to
+// logger. Tokens and passwords are redacted. Request and response bodies are only logged when
+// LogBodies is set. Pass nil to turn logging off again.
+func (client *ProviderClient) SetLogger(logger Logger) {
+	client.logger = logger
+}
+
+// activeLogger returns the Logger set with SetLogger, falling back to stderr when DebugEnv is set.
+func (client *ProviderClient) activeLogger() (Logger, bool) {
+	if client.logger != nil {
+		return client.logger, client.LogBodies
+	}
+	if mode := os.Getenv(DebugEnv); mode != "" {
+		return log.New(os.Stderr, "gophercloud: ", log.LstdFlags), client.LogBodies || mode == "bodies"
+	}
+	return nil, false
+}
+
+// logRequest describes an outgoing request. body is the rendered JSON body, if any.
+func (client *ProviderClient) logRequest(req *http.Request, body []byte) {
+	logger, logBodies := client.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	logger.Printf("Request: %s %s\nHeaders: %s", req.Method, redactURL(req.URL), formatHeaders(req.Header))
+	if logBodies && body != nil {
+		logger.Printf("Request body: %s", redactBody(body))
+	}
+}
+
+// logResponse describes the response to a request. If bodies are logged, at most MaxResponseBytes
+// of the response body are read, and put back in front of the rest of it so that the caller can
+// still consume all of it. A body larger than that isn't logged.
+func (client *ProviderClient) logResponse(req *http.Request, resp *http.Response) {
+	logger, logBodies := client.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	logger.Printf("Response: %d for %s %s\nHeaders: %s", resp.StatusCode, req.Method, redactURL(req.URL), formatHeaders(resp.Header))
+	if logBodies && strings.HasPrefix(resp.Header.Get("Content-Type"), applicationJSON) {
+		body, err := ioutil.ReadAll(client.limitBody(resp.Body))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		if err != nil {
+			logger.Printf("Unable to read response body: %s", err)
+			return
+		}
+		if limit := client.maxResponseBytes(); limit >= 0 && int64(len(body)) > limit {
+			logger.Printf("Response body: (omitted: larger than %d bytes)", limit)
+			return
+		}
+		logger.Printf("Response body: %s", redactBody(body))
+	}
+}
+
+// redactURL renders u with the tokens it may carry redacted: the path segment following "tokens",
+// which names the token being validated or revoked, and the values of redactedQueryParams.
+func redactURL(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i-1] == "tokens" && segments[i] != "" {
+			segments[i] = redacted
+		}
+	}
+
+	base := *u
+	base.Path, base.RawPath, base.RawQuery, base.Fragment = "", "", "", ""
+	rendered := base.String() + strings.Join(segments, "/")
+	if u.RawQuery == "" {
+		return rendered
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	for i, param := range params {
+		name := param
+		if j := strings.Index(param, "="); j >= 0 {
+			name = param[:j]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil && redactedQueryParams[unescaped] {
+			params[i] = name + "=" + redacted
+		}
+	}
+	return rendered + "?" + strings.Join(params, "&")
+}
+
+// formatHeaders renders headers in a stable order, with sensitive values redacted.
+func formatHeaders(headers http.Header) string {
+	hidden := make(map[string]bool, len(redactedHeaders))
+	for _, name := range redactedHeaders {
+		hidden[http.CanonicalHeaderKey(name)] = true
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(headers[name], ", ")
+		if hidden[http.CanonicalHeaderKey(name)] {
+			value = redacted
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// redactBody renders a JSON body with passwords, API keys and token IDs redacted. Bodies that aren't
+// valid JSON are omitted entirely, since they can't be inspected.
+func redactBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return "(omitted: not JSON)"
+	}
+
+	rendered, err := json.Marshal(redactValue(decoded, ""))
+	if err != nil {
+		return "(omitted: " + err.Error() + ")"
+	}
+	return string(rendered)
+}
+
+// redactValue recursively redacts a decoded JSON value. parent is the key under which it was found.
+func redactValue(value interface{}, parent string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if redactedFields[key] || (parent == "token" && key == "id") {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(child, key)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child, parent)
+		}
+	}
+	return value
+}
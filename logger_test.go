@@ -0,0 +1,149 @@
+package gophercloud
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestLoggerRedactsTokens(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Subject-Token", "subjecttoken")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	logger := &recordingLogger{}
+	p := &ProviderClient{TokenID: "secrettoken"}
+	p.SetLogger(logger)
+
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	th.CheckEquals(t, 2, len(logger.messages))
+	th.CheckEquals(t, true, strings.HasPrefix(logger.messages[0], "Request: GET "+th.Endpoint()+"route"))
+	th.CheckEquals(t, true, strings.Contains(logger.messages[0], "X-Auth-Token: ***"))
+	th.CheckEquals(t, true, strings.HasPrefix(logger.messages[1], "Response: 200 for GET "+th.Endpoint()+"route"))
+	th.CheckEquals(t, true, strings.Contains(logger.messages[1], "X-Subject-Token: ***"))
+	for _, message := range logger.messages {
+		if strings.Contains(message, "secrettoken") || strings.Contains(message, "subjecttoken") {
+			t.Errorf("Token leaked into log message: %s", message)
+		}
+	}
+}
+
+func TestLoggerRedactsBodies(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"access": {"token": {"id": "secrettoken", "expires": "2014-01-31T15:30:58Z"}}}`)
+	})
+
+	logger := &recordingLogger{}
+	p := &ProviderClient{LogBodies: true}
+	p.SetLogger(logger)
+
+	var response interface{}
+	_, err := p.Request("POST", th.Endpoint()+"tokens", RequestOpts{
+		JSONBody: map[string]interface{}{
+			"auth": map[string]interface{}{
+				"passwordCredentials": map[string]string{"username": "me", "password": "swordfish"},
+			},
+		},
+		JSONResponse: &response,
+		OkCodes:      []int{200},
+	})
+	th.AssertNoErr(t, err)
+
+	// The response body must still reach the caller after being logged.
+	th.AssertJSONEquals(t, `{"access": {"token": {"id": "secrettoken", "expires": "2014-01-31T15:30:58Z"}}}`, response)
+
+	th.CheckEquals(t, 4, len(logger.messages))
+	th.CheckEquals(t, `Request body: {"auth":{"passwordCredentials":{"password":"***","username":"me"}}}`, logger.messages[1])
+	th.CheckEquals(t, `Response body: {"access":{"token":{"expires":"2014-01-31T15:30:58Z","id":"***"}}}`, logger.messages[3])
+}
+
+func TestLoggerOmitsBodiesByDefault(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	logger := &recordingLogger{}
+	p := &ProviderClient{}
+	p.SetLogger(logger)
+
+	_, err := p.Request("POST", th.Endpoint()+"route", RequestOpts{JSONBody: map[string]string{"password": "swordfish"}})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, 2, len(logger.messages))
+}
+
+func TestLoggerRedactsTokensInURLs(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/tokens/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	logger := &recordingLogger{}
+	p := &ProviderClient{}
+	p.SetLogger(logger)
+
+	_, err := p.Request("GET", th.Endpoint()+"v2.0/tokens/validatedtoken?belongsTo=tenant&x=1", RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	expected := th.Endpoint() + "v2.0/tokens/***?belongsTo=***&x=1"
+	th.CheckEquals(t, true, strings.HasPrefix(logger.messages[0], "Request: GET "+expected+"\n"))
+	th.CheckEquals(t, true, strings.HasPrefix(logger.messages[1], "Response: 200 for GET "+expected+"\n"))
+	for _, message := range logger.messages {
+		if strings.Contains(message, "validatedtoken") {
+			t.Errorf("Token leaked into log message: %s", message)
+		}
+	}
+}
+
+func TestLoggerLimitsResponseBodies(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	large := `{"padding": "` + strings.Repeat("x", 100) + `"}`
+	th.Mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, large)
+	})
+
+	logger := &recordingLogger{}
+	p := &ProviderClient{LogBodies: true, MaxResponseBytes: 50}
+	p.SetLogger(logger)
+
+	resp, err := p.Request("GET", th.Endpoint()+"large", RequestOpts{})
+	th.AssertNoErr(t, err)
+	defer resp.Body.Close()
+
+	th.CheckEquals(t, "Response body: (omitted: larger than 50 bytes)", logger.messages[2])
+
+	// The caller still receives the whole body.
+	body, err := ioutil.ReadAll(resp.Body)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, large, string(body))
+}
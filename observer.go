@@ -0,0 +1,39 @@
+package gophercloud
+
+import "time"
+
+// RequestObserver is notified about the outcome of every HTTP request issued by a ProviderClient,
+// including authentication requests and requests that fail. Use it to feed metrics systems such as
+// Prometheus or statsd.
+type RequestObserver interface {
+	// OnRequestComplete is called once a request has completed. status is the HTTP response code, or
+	// 0 if no response was received at all, and latency covers the time until the response headers
+	// arrived. A request that's replayed after re-authentication is reported once per attempt.
+	OnRequestComplete(method, url string, status int, latency time.Duration)
+}
+
+// RequestObserverFunc adapts an ordinary function to the RequestObserver interface.
+type RequestObserverFunc func(method, url string, status int, latency time.Duration)
+
+// OnRequestComplete calls f(method, url, status, latency).
+func (f RequestObserverFunc) OnRequestComplete(method, url string, status int, latency time.Duration) {
+	f(method, url, status, latency)
+}
+
+// SetObserver directs the client to report the outcome of every request it issues to observer. It's
+// called synchronously, so it should return quickly; a panic within it is recovered so that it
+// can't disrupt the request. Pass nil to stop reporting.
+func (client *ProviderClient) SetObserver(observer RequestObserver) {
+	client.observer = observer
+}
+
+// observe reports a completed request to the client's RequestObserver, if it has one.
+func (client *ProviderClient) observe(method, url string, status int, latency time.Duration) {
+	if client.observer == nil {
+		return
+	}
+	defer func() {
+		recover()
+	}()
+	client.observer.OnRequestComplete(method, url, status, latency)
+}
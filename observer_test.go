@@ -0,0 +1,71 @@
+package gophercloud
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+type observation struct {
+	Method string
+	URL    string
+	Status int
+}
+
+func TestObserverReportsRequests(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	th.Mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	var observed []observation
+	p := &ProviderClient{}
+	p.SetObserver(RequestObserverFunc(func(method, url string, status int, latency time.Duration) {
+		if latency < 0 {
+			t.Errorf("Expected a non-negative latency, but got %s", latency)
+		}
+		observed = append(observed, observation{method, url, status})
+	}))
+
+	_, err := p.Request("GET", th.Endpoint()+"ok", RequestOpts{})
+	th.AssertNoErr(t, err)
+	_, err = p.Request("DELETE", th.Endpoint()+"missing", RequestOpts{})
+	if err == nil {
+		t.Errorf("Expected an error for a 404")
+	}
+	_, err = p.Request("GET", "http://127.0.0.1:0/unreachable", RequestOpts{})
+	if err == nil {
+		t.Errorf("Expected an error for an unreachable endpoint")
+	}
+
+	th.CheckDeepEquals(t, []observation{
+		observation{"GET", th.Endpoint() + "ok", http.StatusOK},
+		observation{"DELETE", th.Endpoint() + "missing", http.StatusNotFound},
+		observation{"GET", "http://127.0.0.1:0/unreachable", 0},
+	}, observed)
+}
+
+func TestObserverPanicIsRecovered(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	p := &ProviderClient{}
+	p.SetObserver(RequestObserverFunc(func(method, url string, status int, latency time.Duration) {
+		panic("observer failure")
+	}))
+
+	resp, err := p.Request("GET", th.Endpoint()+"ok", RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, http.StatusOK, resp.StatusCode)
+}
@@ -3,6 +3,7 @@ package openstack
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/rackspace/gophercloud"
 )
@@ -21,6 +22,9 @@ var (
 // OS_* environment variables.  The following variables provide sources of truth: OS_AUTH_URL, OS_USERNAME,
 // OS_PASSWORD, OS_TENANT_ID, and OS_TENANT_NAME.  Of these, OS_USERNAME, OS_PASSWORD, and OS_AUTH_URL must
 // have settings, or an error will result.  OS_TENANT_ID and OS_TENANT_NAME are optional.
+//
+// If one required variable is missing, the corresponding ErrNo* error is returned; if several are, the
+// error lists all of them.  OS_IDENTITY_API_VERSION, if set, is used as the IdentityVersion.
 func AuthOptionsFromEnv() (gophercloud.AuthOptions, error) {
 	authURL := os.Getenv("OS_AUTH_URL")
 	username := os.Getenv("OS_USERNAME")
@@ -30,17 +34,26 @@ func AuthOptionsFromEnv() (gophercloud.AuthOptions, error) {
 	tenantName := os.Getenv("OS_TENANT_NAME")
 	domainID := os.Getenv("OS_DOMAIN_ID")
 	domainName := os.Getenv("OS_DOMAIN_NAME")
+	identityVersion := os.Getenv("OS_IDENTITY_API_VERSION")
 
+	var missing []string
+	var missingErr error
 	if authURL == "" {
-		return nilOptions, ErrNoAuthURL
+		missing, missingErr = append(missing, "OS_AUTH_URL"), ErrNoAuthURL
 	}
-
 	if username == "" && userID == "" {
-		return nilOptions, ErrNoUsername
+		missing, missingErr = append(missing, "OS_USERNAME"), ErrNoUsername
 	}
-
 	if password == "" {
-		return nilOptions, ErrNoPassword
+		missing, missingErr = append(missing, "OS_PASSWORD"), ErrNoPassword
+	}
+
+	switch len(missing) {
+	case 0:
+	case 1:
+		return nilOptions, missingErr
+	default:
+		return nilOptions, fmt.Errorf("Environment variables %s need to be set.", strings.Join(missing, ", "))
 	}
 
 	ao := gophercloud.AuthOptions{
@@ -52,7 +65,17 @@ func AuthOptionsFromEnv() (gophercloud.AuthOptions, error) {
 		TenantName:       tenantName,
 		DomainID:         domainID,
 		DomainName:       domainName,
+		IdentityVersion:  identityVersion,
 	}
 
 	return ao, nil
 }
+
+// EndpointOptsFromEnv returns the EndpointOpts to use by default with clients configured from the
+// environment. OS_REGION_NAME, if set, provides the Region; otherwise it's left blank, so that
+// endpoints in any region match.
+func EndpointOptsFromEnv() gophercloud.EndpointOpts {
+	return gophercloud.EndpointOpts{
+		Region: os.Getenv("OS_REGION_NAME"),
+	}
+}
@@ -0,0 +1,83 @@
+package openstack
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rackspace/gophercloud"
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+var authEnvVars = []string{
+	"OS_AUTH_URL", "OS_USERNAME", "OS_USERID", "OS_PASSWORD", "OS_TENANT_ID", "OS_TENANT_NAME",
+	"OS_DOMAIN_ID", "OS_DOMAIN_NAME", "OS_IDENTITY_API_VERSION", "OS_REGION_NAME",
+}
+
+// setAuthEnv replaces the OS_* environment with env, returning a function that restores it.
+func setAuthEnv(env map[string]string) func() {
+	saved := make(map[string]string)
+	for _, name := range authEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			saved[name] = value
+		}
+		os.Unsetenv(name)
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+	}
+
+	return func() {
+		for _, name := range authEnvVars {
+			os.Unsetenv(name)
+		}
+		for name, value := range saved {
+			os.Setenv(name, value)
+		}
+	}
+}
+
+func TestAuthOptionsFromEnv(t *testing.T) {
+	defer setAuthEnv(map[string]string{
+		"OS_AUTH_URL":             "https://identity.example.com/v2.0/",
+		"OS_USERNAME":             "me",
+		"OS_PASSWORD":             "swordfish",
+		"OS_TENANT_NAME":          "demo",
+		"OS_IDENTITY_API_VERSION": "2.0",
+	})()
+
+	actual, err := AuthOptionsFromEnv()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, gophercloud.AuthOptions{
+		IdentityEndpoint: "https://identity.example.com/v2.0/",
+		Username:         "me",
+		Password:         "swordfish",
+		TenantName:       "demo",
+		IdentityVersion:  "2.0",
+	}, actual)
+}
+
+func TestAuthOptionsFromEnvMissingOne(t *testing.T) {
+	defer setAuthEnv(map[string]string{
+		"OS_AUTH_URL": "https://identity.example.com/v2.0/",
+		"OS_USERNAME": "me",
+	})()
+
+	_, err := AuthOptionsFromEnv()
+	th.CheckEquals(t, ErrNoPassword, err)
+}
+
+func TestAuthOptionsFromEnvMissingSeveral(t *testing.T) {
+	defer setAuthEnv(map[string]string{"OS_USERNAME": "me"})()
+
+	_, err := AuthOptionsFromEnv()
+	th.CheckEquals(t, "Environment variables OS_AUTH_URL, OS_PASSWORD need to be set.", err.Error())
+}
+
+func TestEndpointOptsFromEnv(t *testing.T) {
+	restore := setAuthEnv(map[string]string{"OS_REGION_NAME": "RegionOne"})
+	th.CheckDeepEquals(t, gophercloud.EndpointOpts{Region: "RegionOne"}, EndpointOptsFromEnv())
+	restore()
+
+	defer setAuthEnv(map[string]string{})()
+	th.CheckDeepEquals(t, gophercloud.EndpointOpts{}, EndpointOptsFromEnv())
+}
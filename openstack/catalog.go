@@ -0,0 +1,86 @@
+package openstack
+
+import (
+	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
+	tokens3 "github.com/rackspace/gophercloud/openstack/identity/v3/tokens"
+)
+
+// CatalogEndpoint is a single URL offered by a service catalog, in a form that's the same whichever
+// version of the identity service produced the catalog. Use NormalizeV2Catalog or NormalizeV3Catalog
+// to obtain them, so that endpoint selection doesn't need to branch on the identity version.
+type CatalogEndpoint struct {
+	// Type and Name describe the service, as in its catalog entry.
+	Type string
+	Name string
+
+	// Region is the region in which the endpoint resides. It may be blank.
+	Region string
+
+	// Availability is the endpoint's interface: public, internal or admin.
+	Availability gophercloud.Availability
+
+	// URL is the endpoint's URL, exactly as the catalog provides it.
+	URL string
+}
+
+// NormalizeV2Catalog flattens a ServiceCatalog acquired from the v2 identity service into
+// CatalogEndpoints. Each v2 endpoint carries up to three URLs, so it's expanded into one
+// CatalogEndpoint per URL, in the order public, internal, admin; URLs that are empty are omitted.
+// Otherwise the catalog's order is preserved.
+func NormalizeV2Catalog(catalog *tokens2.ServiceCatalog) []CatalogEndpoint {
+	var normalized []CatalogEndpoint
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			urls := []struct {
+				availability gophercloud.Availability
+				url          string
+			}{
+				{gophercloud.AvailabilityPublic, endpoint.PublicURL},
+				{gophercloud.AvailabilityInternal, endpoint.InternalURL},
+				{gophercloud.AvailabilityAdmin, endpoint.AdminURL},
+			}
+			for _, u := range urls {
+				if u.url == "" {
+					continue
+				}
+				normalized = append(normalized, CatalogEndpoint{
+					Type:         entry.Type,
+					Name:         entry.Name,
+					Region:       endpoint.Region,
+					Availability: u.availability,
+					URL:          u.url,
+				})
+			}
+		}
+	}
+	return normalized
+}
+
+// NormalizeV3Catalog converts a ServiceCatalog acquired from the v3 identity service into
+// CatalogEndpoints, preserving the catalog's order. Endpoints whose URL is empty or whose interface
+// isn't recognized are omitted, and the region is taken from region_id if region is blank.
+func NormalizeV3Catalog(catalog *tokens3.ServiceCatalog) []CatalogEndpoint {
+	var normalized []CatalogEndpoint
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			availability, err := endpoint.Availability()
+			if err != nil || endpoint.URL == "" {
+				continue
+			}
+
+			region := endpoint.Region
+			if region == "" {
+				region = endpoint.RegionID
+			}
+			normalized = append(normalized, CatalogEndpoint{
+				Type:         entry.Type,
+				Name:         entry.Name,
+				Region:       region,
+				Availability: availability,
+				URL:          endpoint.URL,
+			})
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,51 @@
+package openstack
+
+import (
+	"testing"
+
+	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
+	tokens3 "github.com/rackspace/gophercloud/openstack/identity/v3/tokens"
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestNormalizeV2Catalog(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{Type: "compute", Name: "nova", Endpoints: []tokens2.Endpoint{
+				tokens2.Endpoint{
+					Region:    "RegionOne",
+					PublicURL: "https://compute.example.com/v2/",
+					AdminURL:  "https://admin.compute.example.com/v2/",
+				},
+			}},
+			tokens2.CatalogEntry{Type: "volume", Name: "cinder", Endpoints: []tokens2.Endpoint{
+				tokens2.Endpoint{InternalURL: "https://volume.internal/v1/"},
+			}},
+		},
+	}
+
+	th.CheckDeepEquals(t, []CatalogEndpoint{
+		CatalogEndpoint{"compute", "nova", "RegionOne", gophercloud.AvailabilityPublic, "https://compute.example.com/v2/"},
+		CatalogEndpoint{"compute", "nova", "RegionOne", gophercloud.AvailabilityAdmin, "https://admin.compute.example.com/v2/"},
+		CatalogEndpoint{"volume", "cinder", "", gophercloud.AvailabilityInternal, "https://volume.internal/v1/"},
+	}, NormalizeV2Catalog(&catalog))
+}
+
+func TestNormalizeV3Catalog(t *testing.T) {
+	catalog := tokens3.ServiceCatalog{
+		Entries: []tokens3.CatalogEntry{
+			tokens3.CatalogEntry{Type: "compute", Name: "nova", Endpoints: []tokens3.Endpoint{
+				tokens3.Endpoint{ID: "1", Region: "RegionOne", Interface: "Public", URL: "https://compute.example.com/v2/"},
+				tokens3.Endpoint{ID: "2", RegionID: "RegionTwo", Interface: "internal", URL: "https://compute.internal/v2/"},
+				tokens3.Endpoint{ID: "3", Region: "RegionOne", Interface: "console", URL: "https://console.example.com/"},
+				tokens3.Endpoint{ID: "4", Region: "RegionOne", Interface: "admin"},
+			}},
+		},
+	}
+
+	th.CheckDeepEquals(t, []CatalogEndpoint{
+		CatalogEndpoint{"compute", "nova", "RegionOne", gophercloud.AvailabilityPublic, "https://compute.example.com/v2/"},
+		CatalogEndpoint{"compute", "nova", "RegionTwo", gophercloud.AvailabilityInternal, "https://compute.internal/v2/"},
+	}, NormalizeV3Catalog(&catalog))
+}
@@ -1,13 +1,21 @@
 package openstack
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"sort"
+	"time"
 
 	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack/identity/v2/tenants"
 	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
 	tokens3 "github.com/rackspace/gophercloud/openstack/identity/v3/tokens"
 	"github.com/rackspace/gophercloud/openstack/utils"
+	"github.com/rackspace/gophercloud/pagination"
 )
 
 const (
@@ -19,6 +27,9 @@ const (
 // Most users will probably prefer using the AuthenticatedClient function instead.
 // This is useful if you wish to explicitly control the version of the identity service that's used for authentication explicitly,
 // for example.
+// It's also necessary if the client needs a custom TLS config, a proxy or an existing http.Client for its very first request:
+// configure the client with SetTLSConfig, SetProxy or SetHTTPClient, then call Authenticate.
+// The client's transport starts out with the limits of gophercloud.DefaultConnectionPool.
 func NewClient(endpoint string) (*gophercloud.ProviderClient, error) {
 	u, err := url.Parse(endpoint)
 	if err != nil {
@@ -31,38 +42,104 @@ func NewClient(endpoint string) (*gophercloud.ProviderClient, error) {
 	endpoint = gophercloud.NormalizeURL(endpoint)
 	base = gophercloud.NormalizeURL(base)
 
+	client := &gophercloud.ProviderClient{IdentityBase: base}
 	if hadPath {
-		return &gophercloud.ProviderClient{
-			IdentityBase:     base,
-			IdentityEndpoint: endpoint,
-		}, nil
+		client.IdentityEndpoint = endpoint
 	}
 
-	return &gophercloud.ProviderClient{
-		IdentityBase:     base,
-		IdentityEndpoint: "",
-	}, nil
+	// Size the connection pool for long-running workloads rather than Go's one-shot defaults, unless
+	// http.DefaultTransport has been replaced with a custom RoundTripper, which is left in charge.
+	if err := client.SetConnectionPool(gophercloud.DefaultConnectionPool); err != nil && err != gophercloud.ErrCustomTransport {
+		return nil, err
+	}
+	return client, nil
 }
 
+// AuthRetryAttempts is the number of times AuthenticatedClient tries to reach the identity service,
+// including the first, before giving up. Only failures to connect, such as DNS lookups that fail
+// while a container's network is still starting, are retried; responses from the identity service,
+// including rejected credentials, never are.
+var AuthRetryAttempts = 3
+
+// AuthRetryDelay is how long AuthenticatedClient waits before its first retry. It doubles with each
+// subsequent retry.
+var AuthRetryDelay = 250 * time.Millisecond
+
 // AuthenticatedClient logs in to an OpenStack cloud found at the identity endpoint specified by options, acquires a token, and
 // returns a Client instance that's ready to operate.
 // It first queries the root identity endpoint to determine which versions of the identity service are supported, then chooses
 // the most recent identity service available to proceed.
+// If the identity endpoint can't be reached, each of options.IdentityEndpointFallbacks is tried in turn. The client then keeps
+// using whichever endpoint it authenticated against, including to re-authenticate. If none of them can be reached, they're all
+// tried again after a short delay, up to AuthRetryAttempts times.
 func AuthenticatedClient(options gophercloud.AuthOptions) (*gophercloud.ProviderClient, error) {
-	client, err := NewClient(options.IdentityEndpoint)
-	if err != nil {
-		return nil, err
+	if options.IdentityEndpoint == "" {
+		return nil, ErrNoIdentityEndpoint
 	}
 
-	err = Authenticate(client, options)
-	if err != nil {
-		return nil, err
+	endpoints := append([]string{options.IdentityEndpoint}, options.IdentityEndpointFallbacks...)
+
+	var err error
+	delay := AuthRetryDelay
+	for attempt := 1; ; attempt++ {
+		for _, endpoint := range endpoints {
+			var client *gophercloud.ProviderClient
+			client, err = NewClient(endpoint)
+			if err != nil {
+				return nil, err
+			}
+
+			options.IdentityEndpoint = endpoint
+			err = Authenticate(client, options)
+			if err == nil {
+				return client, nil
+			}
+			if !isConnectionError(err) {
+				return nil, err
+			}
+		}
+
+		if attempt >= AuthRetryAttempts {
+			return nil, err
+		}
+		time.Sleep(delay)
+		delay *= 2
 	}
-	return client, nil
 }
 
-// Authenticate or re-authenticate against the most recent identity service supported at the provided endpoint.
+// isConnectionError reports whether err means the server couldn't be reached at all, because its
+// name couldn't be resolved or a connection to it couldn't be established, as opposed to it
+// responding with an error. Other transport failures, such as a certificate that can't be verified,
+// would fail the same way again, and a cancelled or expired context must not be retried.
+func isConnectionError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Authenticate or re-authenticate against the most recent identity service supported at the provided endpoint, or against
+// the version given by options.IdentityVersion if one is specified.
+// On success, the token, its expiry and the service catalog are stored on the client, replacing those of any earlier
+// authentication; see ProviderClient.TokenExpiresAt and ProviderClient.ServiceCatalog.
 func Authenticate(client *gophercloud.ProviderClient, options gophercloud.AuthOptions) error {
+	switch options.IdentityVersion {
+	case "":
+		// Negotiate a version below.
+	case "2", "2.0", "v2.0":
+		return AuthenticateV2(client, options)
+	case "3", "3.0", "v3", "v3.0":
+		return AuthenticateV3(client, options)
+	default:
+		return fmt.Errorf("Unsupported identity version %q: expected 2.0 or 3", options.IdentityVersion)
+	}
+
 	versions := []*utils.Version{
 		&utils.Version{ID: v20, Priority: 20, Suffix: "/v2.0/"},
 		&utils.Version{ID: v30, Priority: 30, Suffix: "/v3/"},
@@ -75,9 +152,9 @@ func Authenticate(client *gophercloud.ProviderClient, options gophercloud.AuthOp
 
 	switch chosen.ID {
 	case v20:
-		return v2auth(client, endpoint, options)
+		return v2auth(client, endpoint, options, false)
 	case v30:
-		return v3auth(client, endpoint, options)
+		return v3auth(client, endpoint, options, false)
 	default:
 		// The switch statement must be out of date from the versions list.
 		return fmt.Errorf("Unrecognized identity version: %s", chosen.ID)
@@ -86,10 +163,13 @@ func Authenticate(client *gophercloud.ProviderClient, options gophercloud.AuthOp
 
 // AuthenticateV2 explicitly authenticates against the identity v2 endpoint.
 func AuthenticateV2(client *gophercloud.ProviderClient, options gophercloud.AuthOptions) error {
-	return v2auth(client, "", options)
+	return v2auth(client, "", options, false)
 }
 
-func v2auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions) error {
+// v2auth authenticates against the v2 identity service. When re-authenticating with the ReauthFunc it
+// installs, a service catalog that's still fresh according to the client's CatalogTTL is kept, and
+// the one in the response isn't parsed.
+func v2auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions, reauth bool) error {
 	v2Client := NewIdentityV2(client)
 	if endpoint != "" {
 		v2Client.Endpoint = endpoint
@@ -99,21 +179,33 @@ func v2auth(client *gophercloud.ProviderClient, endpoint string, options gopherc
 
 	token, err := result.ExtractToken()
 	if err != nil {
+		if options.SuggestTenants && options.TenantName != "" {
+			return suggestTenants(v2Client, options, err)
+		}
 		return err
 	}
 
-	catalog, err := result.ExtractServiceCatalog()
-	if err != nil {
-		return err
+	catalog, keep := client.ServiceCatalog().(*tokens2.ServiceCatalog)
+	keep = keep && reauth && client.CatalogFresh()
+	if !keep {
+		catalog, err = result.ExtractServiceCatalog()
+		if err != nil {
+			return err
+		}
 	}
 
-	if options.AllowReauth {
+	// The ReauthFunc installed by the initial authentication is kept, since concurrent requests may be
+	// reading it while it re-authenticates.
+	if options.AllowReauth && !reauth {
 		client.ReauthFunc = func() error {
-			client.TokenID = ""
-			return AuthenticateV2(client, options)
+			return v2auth(client, "", options, true)
 		}
 	}
-	client.TokenID = token.ID
+	if keep {
+		client.RenewToken(token.ID, token.ExpiresAt)
+	} else {
+		client.SetAuthentication(token.ID, token.ExpiresAt, catalog)
+	}
 	client.EndpointLocator = func(opts gophercloud.EndpointOpts) (string, error) {
 		return V2EndpointURL(catalog, opts)
 	}
@@ -121,12 +213,64 @@ func v2auth(client *gophercloud.ProviderClient, endpoint string, options gopherc
 	return nil
 }
 
+// suggestTenants turns the rejection of authentication scoped by TenantName into an
+// *ErrTenantNotFound naming the tenants the user can access. It authenticates again without a
+// tenant to list them, and returns the original error if the rejection had some other cause.
+func suggestTenants(identity *gophercloud.ServiceClient, options gophercloud.AuthOptions, authErr error) error {
+	if respErr, ok := authErr.(*gophercloud.UnexpectedResponseCodeError); !ok || respErr.Actual != http.StatusUnauthorized {
+		return authErr
+	}
+
+	// Use a separate client, so that the unscoped token isn't left on the caller's.
+	unscoped := NewIdentityV2(&gophercloud.ProviderClient{
+		IdentityBase:     identity.IdentityBase,
+		IdentityEndpoint: identity.IdentityEndpoint,
+		HTTPClient:       identity.HTTPClient,
+		UserAgent:        identity.UserAgent,
+	})
+	unscoped.Endpoint = identity.Endpoint
+
+	requested := options.TenantName
+	options.TenantID, options.TenantName = "", ""
+	token, err := tokens2.Create(unscoped, tokens2.WrapOptions(options)).ExtractToken()
+	if err != nil {
+		return authErr
+	}
+	unscoped.SetToken(token.ID)
+
+	var available []string
+	err = tenants.List(unscoped, nil).EachPage(func(page pagination.Page) (bool, error) {
+		tenantList, err := tenants.ExtractTenants(page)
+		if err != nil {
+			return false, err
+		}
+		for _, tenant := range tenantList {
+			available = append(available, tenant.Name)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return authErr
+	}
+
+	for _, name := range available {
+		if name == requested {
+			// The tenant exists, so it wasn't the cause.
+			return authErr
+		}
+	}
+	sort.Strings(available)
+	return &ErrTenantNotFound{TenantName: requested, Available: available, Err: authErr}
+}
+
 // AuthenticateV3 explicitly authenticates against the identity v3 service.
 func AuthenticateV3(client *gophercloud.ProviderClient, options gophercloud.AuthOptions) error {
-	return v3auth(client, "", options)
+	return v3auth(client, "", options, false)
 }
 
-func v3auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions) error {
+// v3auth authenticates against the v3 identity service, keeping a fresh service catalog when
+// re-authenticating as v2auth does.
+func v3auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions, reauth bool) error {
 	// Override the generated service endpoint with the one returned by the version endpoint.
 	v3Client := NewIdentityV3(client)
 	if endpoint != "" {
@@ -158,17 +302,24 @@ func v3auth(client *gophercloud.ProviderClient, endpoint string, options gopherc
 		return err
 	}
 
-	catalog, err := result.ExtractServiceCatalog()
-	if err != nil {
-		return err
+	catalog, keep := client.ServiceCatalog().(*tokens3.ServiceCatalog)
+	keep = keep && reauth && client.CatalogFresh()
+	if !keep {
+		catalog, err = result.ExtractServiceCatalog()
+		if err != nil {
+			return err
+		}
 	}
 
-	client.TokenID = token.ID
+	if keep {
+		client.RenewToken(token.ID, token.ExpiresAt)
+	} else {
+		client.SetAuthentication(token.ID, token.ExpiresAt, catalog)
+	}
 
-	if options.AllowReauth {
+	if options.AllowReauth && !reauth {
 		client.ReauthFunc = func() error {
-			client.TokenID = ""
-			return AuthenticateV3(client, options)
+			return v3auth(client, "", options, true)
 		}
 	}
 	client.EndpointLocator = func(opts gophercloud.EndpointOpts) (string, error) {
@@ -178,6 +329,28 @@ func v3auth(client *gophercloud.ProviderClient, endpoint string, options gopherc
 	return nil
 }
 
+// NewServiceClient creates a ServiceClient for the service described by eo from a Token and
+// ServiceCatalog acquired from the v2 identity service, such as those extracted from a
+// tokens.CreateResult. The token and catalog are stored on the provider, as Authenticate would, so
+// that requests made with any of its ServiceClients authenticate with the token. The endpoint is resolved as V2EndpointURL does,
+// unless the provider has an EndpointOverrides entry for eo.Type, and the same errors are reported if
+// no endpoint, or more than one, matches eo.
+func NewServiceClient(provider *gophercloud.ProviderClient, token *tokens2.Token, catalog *tokens2.ServiceCatalog, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
+	url, ok := provider.EndpointOverrides[eo.Type]
+	if ok {
+		url = gophercloud.NormalizeURL(url)
+	} else {
+		var err error
+		url, err = V2EndpointURL(catalog, eo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	provider.SetAuthentication(token.ID, token.ExpiresAt, catalog)
+	return &gophercloud.ServiceClient{ProviderClient: provider, Endpoint: url, Region: eo.Region}, nil
+}
+
 // NewIdentityV2 creates a ServiceClient that may be used to interact with the v2 identity service.
 func NewIdentityV2(client *gophercloud.ProviderClient) *gophercloud.ServiceClient {
 	v2Endpoint := client.IdentityBase + "v2.0/"
@@ -201,33 +374,34 @@ func NewIdentityV3(client *gophercloud.ProviderClient) *gophercloud.ServiceClien
 // NewObjectStorageV1 creates a ServiceClient that may be used with the v1 object storage package.
 func NewObjectStorageV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("object-store")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewComputeV2 creates a ServiceClient that may be used with the v2 compute package.
 func NewComputeV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("compute")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewNetworkV2 creates a ServiceClient that may be used with the v2 network package.
 func NewNetworkV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("network")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
 	return &gophercloud.ServiceClient{
 		ProviderClient: client,
 		Endpoint:       url,
+		Region:         eo.Region,
 		ResourceBase:   url + "v2.0/",
 	}, nil
 }
@@ -235,30 +409,30 @@ func NewNetworkV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpt
 // NewBlockStorageV1 creates a ServiceClient that may be used to access the v1 block storage service.
 func NewBlockStorageV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("volume")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewCDNV1 creates a ServiceClient that may be used to access the OpenStack v1
 // CDN service.
 func NewCDNV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("cdn")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewOrchestrationV1 creates a ServiceClient that may be used to access the v1 orchestration service.
 func NewOrchestrationV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("orchestration")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
@@ -1,12 +1,25 @@
 package openstack
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
 	th "github.com/rackspace/gophercloud/testhelper"
+	"github.com/rackspace/gophercloud/testhelper/identity"
 )
 
 func TestAuthenticatedClientV3(t *testing.T) {
@@ -159,3 +172,461 @@ func TestAuthenticatedClientV2(t *testing.T) {
 	th.AssertNoErr(t, err)
 	th.CheckEquals(t, "01234567890", client.TokenID)
 }
+
+func TestAuthenticatedClientRequiresIdentityEndpoint(t *testing.T) {
+	_, err := AuthenticatedClient(gophercloud.AuthOptions{Username: "me", Password: "secret"})
+	th.CheckEquals(t, ErrNoIdentityEndpoint, err)
+}
+
+func TestAuthenticateV2WithTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `
+			{
+				"access": {
+					"token": {
+						"id": "01234567890",
+						"expires": "2014-10-01T10:00:00.000000Z"
+					},
+					"serviceCatalog": []
+				}
+			}
+		`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL)
+	th.AssertNoErr(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	th.AssertNoErr(t, client.SetTLSConfig(&tls.Config{RootCAs: pool}))
+
+	err = AuthenticateV2(client, gophercloud.AuthOptions{Username: "me", Password: "secret"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "01234567890", client.TokenID)
+}
+
+func TestAuthenticateWithIdentityVersion(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	// No version document is served, so negotiation would fail.
+	th.Mux.HandleFunc("/v2.0/tokens", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `
+			{
+				"access": {
+					"token": {
+						"id": "01234567890",
+						"expires": "2014-10-01T10:00:00.000000Z"
+					},
+					"serviceCatalog": []
+				}
+			}
+		`)
+	})
+
+	options := gophercloud.AuthOptions{
+		Username:         "me",
+		Password:         "secret",
+		IdentityEndpoint: th.Endpoint(),
+		IdentityVersion:  "2.0",
+	}
+	client, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "01234567890", client.TokenID)
+}
+
+func TestAuthenticateWithUnsupportedIdentityVersion(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		Username:         "me",
+		Password:         "secret",
+		IdentityEndpoint: "http://localhost:5000/",
+		IdentityVersion:  "4",
+	}
+	_, err := AuthenticatedClient(options)
+	th.CheckEquals(t, `Unsupported identity version "4": expected 2.0 or 3`, err.Error())
+}
+
+func TestNewComputeV2WithEndpointOverride(t *testing.T) {
+	client := &gophercloud.ProviderClient{
+		EndpointLocator: func(eo gophercloud.EndpointOpts) (string, error) {
+			return V2EndpointURL(&catalog2, eo)
+		},
+		EndpointOverrides: map[string]string{"same": "http://localhost:8774/"},
+	}
+
+	// The catalog holds several matching endpoints, but the override skips it entirely.
+	compute, err := NewComputeV2(client, gophercloud.EndpointOpts{Type: "same"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://localhost:8774/", compute.Endpoint)
+}
+
+func TestNewServiceClient(t *testing.T) {
+	provider := &gophercloud.ProviderClient{}
+	token := &tokens2.Token{ID: "aaaabbbbccccdddd"}
+
+	client, err := NewServiceClient(provider, token, &catalog2, gophercloud.EndpointOpts{
+		Type:         "same",
+		Name:         "same",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityInternal,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://internal.correct.com/", client.Endpoint)
+	th.CheckEquals(t, "same", client.Region)
+	th.CheckEquals(t, "aaaabbbbccccdddd", client.TokenID)
+	th.CheckDeepEquals(t, map[string]string{"X-Auth-Token": "aaaabbbbccccdddd"}, client.AuthenticatedHeaders())
+}
+
+func TestNewServiceClientAmbiguous(t *testing.T) {
+	provider := &gophercloud.ProviderClient{}
+	token := &tokens2.Token{ID: "aaaabbbbccccdddd"}
+
+	_, err := NewServiceClient(provider, token, &catalog2, gophercloud.EndpointOpts{Type: "same", Region: "same"})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints, but got %#v", err)
+	}
+	th.CheckEquals(t, "", provider.TokenID)
+}
+
+func TestAuthenticatedClientWithFakeIdentity(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	server.SetCatalog(identity.Service{
+		Type: "compute",
+		Endpoints: []identity.Endpoint{
+			identity.Endpoint{Region: "RegionOne", PublicURL: "http://compute1.example.com/v2/"},
+			identity.Endpoint{Region: "RegionTwo", PublicURL: "http://compute2.example.com/v2/"},
+		},
+	})
+
+	options := gophercloud.AuthOptions{
+		IdentityEndpoint: server.Endpoint(),
+		Username:         "me",
+		Password:         "swordfish",
+		TenantID:         "tenant",
+	}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "token-1", provider.TokenID)
+
+	compute, err := NewComputeV2(provider, gophercloud.EndpointOpts{Region: "RegionTwo"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://compute2.example.com/v2/", compute.Endpoint)
+
+	_, err = NewComputeV2(provider, gophercloud.EndpointOpts{})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an ErrMultipleEndpoints, but got %#v", err)
+	}
+
+	th.AssertNoErr(t, Authenticate(provider, options))
+	th.CheckEquals(t, "token-2", provider.TokenID)
+	th.CheckEquals(t, 2, server.Requests())
+
+	server.SetResponse(http.StatusOK, `{"access": {"token": {"id": "malformed", "expires": "tomorrow"}}}`)
+	if err := Authenticate(provider, options); err == nil {
+		t.Errorf("Expected an error for a malformed expiry, but got none")
+	}
+}
+
+func TestAuthenticatedClientFailsOverOnConnectionError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{
+		IdentityEndpoint:          down.URL + "/v2.0/",
+		IdentityEndpointFallbacks: []string{server.Endpoint()},
+		Username:                  "me",
+		Password:                  "swordfish",
+	}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "token-1", provider.Token())
+	th.CheckEquals(t, server.URL+"/", provider.IdentityBase)
+}
+
+func TestAuthenticatedClientDoesNotFailOverOnRejection(t *testing.T) {
+	rejecting := identity.NewServer()
+	defer rejecting.Close()
+	rejecting.SetResponse(http.StatusUnauthorized, `{"error": {"code": 401, "title": "Unauthorized"}}`)
+
+	fallback := identity.NewServer()
+	defer fallback.Close()
+
+	options := gophercloud.AuthOptions{
+		IdentityEndpoint:          rejecting.Endpoint(),
+		IdentityEndpointFallbacks: []string{fallback.Endpoint()},
+		Username:                  "me",
+		Password:                  "wrong",
+	}
+	_, err := AuthenticatedClient(options)
+	if _, ok := err.(*gophercloud.UnexpectedResponseCodeError); !ok {
+		t.Errorf("Expected an *UnexpectedResponseCodeError, but got %#v", err)
+	}
+	th.CheckEquals(t, 1, rejecting.Requests())
+	th.CheckEquals(t, 0, fallback.Requests())
+}
+
+func TestAuthenticateStoresTokenAndCatalog(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	expiresAt := time.Date(2030, 1, 31, 15, 30, 58, 0, time.UTC)
+	server.SetToken(identity.Token{ExpiresAt: expiresAt})
+	server.SetCatalog(identity.Service{
+		Type:      "compute",
+		Endpoints: []identity.Endpoint{identity.Endpoint{PublicURL: "http://compute.example.com/v2/"}},
+	})
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "token-1", provider.Token())
+	th.CheckEquals(t, true, expiresAt.Equal(provider.TokenExpiresAt()))
+
+	catalog, ok := provider.ServiceCatalog().(*tokens2.ServiceCatalog)
+	if !ok {
+		t.Fatalf("Expected a *tokens.ServiceCatalog, but got %#v", provider.ServiceCatalog())
+	}
+	th.CheckEquals(t, "compute", catalog.Entries[0].Type)
+
+	server.SetCatalog()
+	th.AssertNoErr(t, Authenticate(provider, options))
+	th.CheckEquals(t, "token-2", provider.Token())
+	th.CheckEquals(t, 0, len(provider.ServiceCatalog().(*tokens2.ServiceCatalog).Entries))
+}
+
+func TestReauthenticateKeepsFreshCatalog(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+	server.SetCatalog(identity.Service{
+		Type:      "compute",
+		Endpoints: []identity.Endpoint{identity.Endpoint{PublicURL: "http://compute.example.com/v2/"}},
+	})
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish", AllowReauth: true}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	provider.CatalogTTL = time.Hour
+	th.CheckEquals(t, true, provider.CatalogFresh())
+
+	// While it's fresh, re-authenticating only replaces the token.
+	server.SetCatalog()
+	th.AssertNoErr(t, provider.ReauthFunc())
+	th.CheckEquals(t, "token-2", provider.Token())
+	th.CheckEquals(t, 1, len(provider.ServiceCatalog().(*tokens2.ServiceCatalog).Entries))
+	url, err := provider.LocateEndpoint(gophercloud.EndpointOpts{Type: "compute"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://compute.example.com/v2/", url)
+
+	// RefreshCatalog replaces it regardless.
+	th.AssertNoErr(t, provider.RefreshCatalog())
+	th.CheckEquals(t, "token-3", provider.Token())
+	th.CheckEquals(t, 0, len(provider.ServiceCatalog().(*tokens2.ServiceCatalog).Entries))
+	th.CheckEquals(t, true, provider.CatalogFresh())
+}
+
+// gatedTransport holds back authentication requests, reporting each one on started, until release
+// is closed.
+type gatedTransport struct {
+	started chan struct{}
+	release chan struct{}
+	next    http.RoundTripper
+}
+
+func (t *gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/tokens") {
+		t.started <- struct{}{}
+		<-t.release
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestReauthenticateKeepsTokenForConcurrentRequests(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	seen := make(chan string, 10)
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Auth-Token")
+		seen <- token
+		if token != "token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer service.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish", AllowReauth: true}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+
+	gate := &gatedTransport{started: make(chan struct{}), release: make(chan struct{}), next: http.DefaultTransport}
+	provider.HTTPClient.Transport = gate
+
+	var wg sync.WaitGroup
+	request := func() {
+		defer wg.Done()
+		_, err := provider.Request("GET", service.URL+"/route", gophercloud.RequestOpts{})
+		th.CheckNoErr(t, err)
+	}
+
+	// The first request is rejected, and re-authenticates.
+	wg.Add(1)
+	go request()
+	th.CheckEquals(t, "token-1", <-seen)
+	<-gate.started
+
+	// A request that starts meanwhile still sends the old token, and is replayed once the new one
+	// is stored.
+	wg.Add(1)
+	go request()
+	th.CheckEquals(t, "token-1", <-seen)
+
+	close(gate.release)
+	wg.Wait()
+	close(seen)
+	var replayed []string
+	for token := range seen {
+		replayed = append(replayed, token)
+	}
+	th.CheckDeepEquals(t, []string{"token-2", "token-2"}, replayed)
+	th.CheckEquals(t, 2, server.Requests())
+}
+
+// flakyTransport fails the first failures requests as a failed DNS lookup would.
+type flakyTransport struct {
+	failures int
+	next     http.RoundTripper
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.failures > 0 {
+		t.failures--
+		return nil, &net.DNSError{Err: "no such host", Name: req.URL.Host}
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestAuthenticatedClientRetriesConnectionErrors(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	defaultTransport, delay := http.DefaultTransport, AuthRetryDelay
+	http.DefaultTransport = &flakyTransport{failures: 2, next: defaultTransport}
+	AuthRetryDelay = time.Millisecond
+	defer func() { http.DefaultTransport, AuthRetryDelay = defaultTransport, delay }()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "token-1", provider.Token())
+
+	http.DefaultTransport = &flakyTransport{failures: AuthRetryAttempts, next: defaultTransport}
+	_, err = AuthenticatedClient(options)
+	if _, ok := err.(*url.Error); !ok {
+		t.Errorf("Expected a *url.Error once the retries were exhausted, but got %#v", err)
+	}
+	th.CheckEquals(t, 1, server.Requests())
+}
+
+func TestIsConnectionError(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	th.CheckEquals(t, true, isConnectionError(&url.Error{Op: "Post", URL: "http://identity", Err: dial}))
+	th.CheckEquals(t, true, isConnectionError(&url.Error{Op: "Post", URL: "http://identity", Err: &net.DNSError{Err: "no such host"}}))
+
+	read := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	th.CheckEquals(t, false, isConnectionError(&url.Error{Op: "Post", URL: "http://identity", Err: read}))
+	th.CheckEquals(t, false, isConnectionError(&url.Error{Op: "Post", URL: "https://identity", Err: x509.UnknownAuthorityError{}}))
+	th.CheckEquals(t, false, isConnectionError(&url.Error{Op: "Post", URL: "http://identity", Err: context.Canceled}))
+	th.CheckEquals(t, false, isConnectionError(&url.Error{Op: "Post", URL: "http://identity", Err: context.DeadlineExceeded}))
+	th.CheckEquals(t, false, isConnectionError(&url.Error{Op: "parse", URL: "::", Err: errors.New("missing protocol scheme")}))
+}
+
+func TestAuthenticateV2SuggestsTenants(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/tokens", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Auth struct {
+				TenantName string `json:"tenantName"`
+			} `json:"auth"`
+		}
+		th.AssertNoErr(t, json.NewDecoder(r.Body).Decode(&body))
+		if body.Auth.TenantName != "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error": {"code": 401, "title": "Unauthorized", "message": "The request you have made requires authentication."}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access": {"token": {"id": "unscoped", "expires": "2014-10-01T10:00:00.000000Z"}, "serviceCatalog": []}}`)
+	})
+	th.Mux.HandleFunc("/v2.0/tenants", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "X-Auth-Token", "unscoped")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"tenants": [{"id": "2", "name": "staging"}, {"id": "1", "name": "production"}]}`)
+	})
+
+	options := gophercloud.AuthOptions{
+		Username:   "me",
+		Password:   "secret",
+		TenantName: "prod",
+	}
+
+	client, err := NewClient(th.Endpoint())
+	th.AssertNoErr(t, err)
+	client.IdentityBase = th.Endpoint()
+	client.IdentityEndpoint = th.Endpoint() + "v2.0/"
+
+	// Without SuggestTenants, the rejection is returned as-is.
+	err = AuthenticateV2(client, options)
+	if _, ok := err.(*gophercloud.UnexpectedResponseCodeError); !ok {
+		t.Fatalf("Expected an *UnexpectedResponseCodeError, but got %#v", err)
+	}
+
+	options.SuggestTenants = true
+	err = AuthenticateV2(client, options)
+	notFound, ok := err.(*ErrTenantNotFound)
+	if !ok {
+		t.Fatalf("Expected an *ErrTenantNotFound, but got %#v", err)
+	}
+	th.CheckEquals(t, "prod", notFound.TenantName)
+	th.CheckDeepEquals(t, []string{"production", "staging"}, notFound.Available)
+	th.CheckEquals(t, `Tenant "prod" was not found. The user can access: production, staging`, notFound.Error())
+	if _, ok := notFound.Err.(*gophercloud.UnexpectedResponseCodeError); !ok {
+		t.Errorf("Expected the original *UnexpectedResponseCodeError, but got %#v", notFound.Err)
+	}
+	th.CheckEquals(t, "", client.Token())
+
+	// A tenant the user can access wasn't the cause, so the rejection is returned as-is.
+	options.TenantName = "production"
+	err = AuthenticateV2(client, options)
+	if _, ok := err.(*gophercloud.UnexpectedResponseCodeError); !ok {
+		t.Errorf("Expected an *UnexpectedResponseCodeError, but got %#v", err)
+	}
+}
+
+func TestNewClientAppliesDefaultConnectionPool(t *testing.T) {
+	client, err := NewClient("http://identity.example.com:5000/")
+	th.AssertNoErr(t, err)
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, but got %#v", client.HTTPClient.Transport)
+	}
+	th.CheckEquals(t, gophercloud.DefaultConnectionPool.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	th.CheckEquals(t, gophercloud.DefaultConnectionPool.MaxIdleConns, transport.MaxIdleConns)
+
+	// A custom http.DefaultTransport is left in charge.
+	defer func(original http.RoundTripper) { http.DefaultTransport = original }(http.DefaultTransport)
+	http.DefaultTransport = &flakyTransport{next: http.DefaultTransport}
+	client, err = NewClient("http://identity.example.com:5000/")
+	th.AssertNoErr(t, err)
+	if client.HTTPClient.Transport != nil {
+		t.Errorf("Expected no transport of the client's own, but got %#v", client.HTTPClient.Transport)
+	}
+}
@@ -2,6 +2,7 @@ package openstack
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/rackspace/gophercloud"
 	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
@@ -11,81 +12,401 @@ import (
 // V2EndpointURL discovers the endpoint URL for a specific service from a ServiceCatalog acquired
 // during the v2 identity service. The specified EndpointOpts are used to identify a unique,
 // unambiguous endpoint to return. It's an error both when multiple endpoints match the provided
-// criteria and when none do. The minimum that can be specified is a Type, but you will also often
-// need to specify a Name and/or a Region depending on what's available on your OpenStack
-// deployment.
+// criteria, in which case the error lists the candidates, and when none do, in which case it's a
+// *gophercloud.EndpointNotFoundError wrapping gophercloud.ErrServiceNotInCatalog if the catalog has
+// no service of the requested Type and gophercloud.ErrNoEndpointForCriteria if it does. The minimum that can be
+// specified is a Type, but you will also often need to specify a Name and/or a Region depending on
+// what's available on your OpenStack deployment. If no Availability is specified, the public
+// endpoint is chosen.
 func V2EndpointURL(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) (string, error) {
-	// Extract Endpoints from the catalog entries that match the requested Type, Name if provided, and Region if provided.
-	var endpoints = make([]tokens2.Endpoint, 0, 1)
-	for _, entry := range catalog.Entries {
-		if (entry.Type == opts.Type) && (opts.Name == "" || entry.Name == opts.Name) {
-			for _, endpoint := range entry.Endpoints {
-				if opts.Region == "" || endpoint.Region == opts.Region {
-					endpoints = append(endpoints, endpoint)
+	_, url, err := v2Find(catalog, defaultAvailability(opts), nil)
+	return url, err
+}
+
+// V2EndpointURLWithTrace chooses an endpoint URL exactly as V2EndpointURL does, and also explains
+// how: the trace describes, in catalog order, why each catalog entry and each of the endpoints of
+// the entries that matched was included or excluded, followed by the outcome. Use it to understand
+// an unexpected choice, or an ambiguous or not-found error.
+func V2EndpointURLWithTrace(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) (string, []string, error) {
+	trace := endpointTrace{}
+	_, url, err := v2Find(catalog, defaultAvailability(opts), &trace)
+	return url, trace, err
+}
+
+// V2CatalogEntry finds the catalog entry listing the endpoint that V2EndpointURL would choose for
+// opts, for callers that need more context than a URL, such as the service's name or its other
+// endpoints. The same endpoints match, and the same errors are reported when several or none do, or
+// when the matching endpoint doesn't offer a suitable URL. The entry returned is a copy, including
+// its Endpoints, so altering it leaves the catalog as it was.
+func V2CatalogEntry(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) (*tokens2.CatalogEntry, error) {
+	match, _, err := v2Find(catalog, defaultAvailability(opts), nil)
+	if err != nil {
+		return nil, err
+	}
+	entry := *match.entry
+	entry.Endpoints = append([]tokens2.Endpoint(nil), entry.Endpoints...)
+	return &entry, nil
+}
+
+// V2EndpointURLs discovers every endpoint URL for a specific service from a ServiceCatalog acquired
+// during the v2 identity service. Unlike V2EndpointURL, it isn't an error for several endpoints to
+// match the provided EndpointOpts: the URLs of all of them are returned, in the order in which they
+// appear in the catalog, except that any tripped by opts.Breaker come last. It's still an error when
+// no endpoints match.
+func V2EndpointURLs(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) ([]string, error) {
+	opts = defaultAvailability(opts)
+	endpoints := v2Endpoints(catalog, opts)
+	if len(endpoints) == 0 {
+		return nil, v2EndpointNotFound(catalog, opts)
+	}
+
+	urls := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		url, err := v2URL(endpoint, opts)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return opts.Breaker.Prioritize(urls), nil
+}
+
+// ResolveEndpoints discovers the endpoint URL for each of several service types from a
+// ServiceCatalog acquired during the v2 identity service, as V2EndpointURL would. The base
+// EndpointOpts are used for every lookup, with their Type replaced by each of the types in turn.
+//
+// The result maps each service type to its URL. If any of them can't be resolved, an
+// *ErrResolveEndpoints describing every failure is returned alongside the URLs that were found.
+func ResolveEndpoints(catalog *tokens2.ServiceCatalog, base gophercloud.EndpointOpts, types []string) (map[string]string, error) {
+	urls := make(map[string]string, len(types))
+	failures := make(map[string]error)
+
+	for _, serviceType := range types {
+		url, err := V2EndpointURL(catalog, base.WithType(serviceType))
+		if err != nil {
+			failures[serviceType] = err
+			continue
+		}
+		urls[serviceType] = url
+	}
+
+	if len(failures) > 0 {
+		return urls, &ErrResolveEndpoints{Errors: failures}
+	}
+	return urls, nil
+}
+
+// SelectEndpoint chooses an endpoint URL for a specific service from a ServiceCatalog acquired during
+// the v2 identity service, following a fixed order of precedence rather than reporting ambiguity:
+//
+//  1. Only endpoints matching opts are considered, exactly as for V2EndpointURL. In particular, a
+//     Region in opts is a requirement, not a preference.
+//  2. Endpoints in regions listed in regionPreference are preferred in the order given, followed by
+//     those in any other region.
+//  3. Within that order, the first endpoint offering a URL with opts.Availability, or failing that
+//     one of opts.AvailabilityFallbacks, is chosen. If no Availability is specified, public
+//     endpoints are chosen.
+//  4. Endpoints that rank equally are taken in the order in which they appear in the catalog.
+//
+// It's an error when no endpoints match, or when none of those that do offer a suitable URL.
+func SelectEndpoint(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts, regionPreference []string) (string, error) {
+	opts = defaultAvailability(opts)
+	endpoints := v2Endpoints(catalog, opts)
+	if len(endpoints) == 0 {
+		return "", v2EndpointNotFound(catalog, opts)
+	}
+
+	rank := func(region string) int {
+		for i, preferred := range regionPreference {
+			if region == preferred {
+				return i
+			}
+		}
+		return len(regionPreference)
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool {
+		return rank(endpoints[i].Region) < rank(endpoints[j].Region)
+	})
+
+	for _, endpoint := range endpoints {
+		url, err := v2URL(endpoint, opts)
+		if err == tokens2.ErrEndpointURLMissing {
+			continue
+		}
+		return url, err
+	}
+	return "", tokens2.ErrEndpointURLMissing
+}
+
+// v2Endpoints extracts Endpoints from the catalog entries that match the requested Type, Name or
+// NamePrefix if provided, Region if provided, VersionID if provided, and TenantID if provided. If
+// opts.DeduplicateURLs is set, endpoints whose URL repeats that of an earlier one are dropped.
+func v2Endpoints(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) []tokens2.Endpoint {
+	matches := v2Matches(catalog, opts, nil)
+	var endpoints = make([]tokens2.Endpoint, 0, len(matches))
+	for _, match := range matches {
+		endpoints = append(endpoints, match.endpoint)
+	}
+	return endpoints
+}
+
+// v2Match is an endpoint that matched an endpoint query, along with the catalog entry listing it.
+type v2Match struct {
+	entry    *tokens2.CatalogEntry
+	endpoint tokens2.Endpoint
+}
+
+// v2Matches finds the endpoints that v2Endpoints extracts, along with their catalog entries. If trace
+// isn't nil, the reason each entry and endpoint was included or excluded is recorded in it.
+func v2Matches(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts, trace *endpointTrace) []v2Match {
+	var matches = make([]v2Match, 0, 1)
+	seen := make(map[string]bool)
+	for i, entry := range catalog.Entries {
+		if !opts.MatchType(entry.Type) {
+			trace.add("entry %q excluded: type %q doesn't match %q", entry.Name, entry.Type, opts.Type)
+			continue
+		}
+		if !opts.MatchName(entry.Name) {
+			trace.add("entry %q excluded: name doesn't match name=%q name-prefix=%q", entry.Name, opts.Name, opts.NamePrefix)
+			continue
+		}
+		trace.add("entry %q matched type %q", entry.Name, entry.Type)
+
+		for j, endpoint := range entry.Endpoints {
+			switch {
+			case opts.Region != "" && endpoint.Region != opts.Region:
+				trace.add("entry %q endpoint %d excluded: region %q != %q", entry.Name, j, endpoint.Region, opts.Region)
+				continue
+			case opts.VersionID != "" && endpoint.VersionID != opts.VersionID:
+				trace.add("entry %q endpoint %d excluded: version %q != %q", entry.Name, j, endpoint.VersionID, opts.VersionID)
+				continue
+			case opts.TenantID != "" && endpoint.TenantID != opts.TenantID:
+				trace.add("entry %q endpoint %d excluded: tenant %q != %q", entry.Name, j, endpoint.TenantID, opts.TenantID)
+				continue
+			}
+			if opts.DeduplicateURLs {
+				// Endpoints without a usable URL are kept, so that the error is still reported.
+				if url, err := v2URL(endpoint, opts); err == nil {
+					if seen[url] {
+						trace.add("entry %q endpoint %d excluded: URL %s duplicates an earlier endpoint", entry.Name, j, url)
+						continue
+					}
+					seen[url] = true
 				}
 			}
+			trace.add("entry %q endpoint %d matched in region %q", entry.Name, j, endpoint.Region)
+			matches = append(matches, v2Match{entry: &catalog.Entries[i], endpoint: endpoint})
 		}
 	}
+	return matches
+}
+
+// v2Find chooses the one endpoint that matches opts, returning it along with its URL. It's an error
+// when several endpoints match, when none do, or when the one that does has no suitable URL. If
+// trace isn't nil, the matching decisions and the outcome are recorded in it.
+func v2Find(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts, trace *endpointTrace) (*v2Match, string, error) {
+	matches := v2Matches(catalog, opts, trace)
 
 	// Report an error if the options were ambiguous.
-	if len(endpoints) > 1 {
-		return "", fmt.Errorf("Discovered %d matching endpoints: %#v", len(endpoints), endpoints)
+	if len(matches) > 1 {
+		endpoints := make([]tokens2.Endpoint, 0, len(matches))
+		for _, match := range matches {
+			endpoints = append(endpoints, match.endpoint)
+		}
+		trace.add("%d endpoints matched, so the choice is ambiguous", len(matches))
+		return nil, "", &ErrMultipleEndpoints{Opts: opts, Endpoints: endpoints}
+	}
+
+	// Report an error if there were no matching endpoints.
+	if len(matches) == 0 {
+		trace.add("no endpoints matched")
+		return nil, "", v2EndpointNotFound(catalog, opts)
 	}
 
 	// Extract the appropriate URL from the matching Endpoint.
-	for _, endpoint := range endpoints {
-		switch opts.Availability {
-		case gophercloud.AvailabilityPublic:
-			return gophercloud.NormalizeURL(endpoint.PublicURL), nil
-		case gophercloud.AvailabilityInternal:
-			return gophercloud.NormalizeURL(endpoint.InternalURL), nil
-		case gophercloud.AvailabilityAdmin:
-			return gophercloud.NormalizeURL(endpoint.AdminURL), nil
-		default:
-			return "", fmt.Errorf("Unexpected availability in endpoint query: %s", opts.Availability)
+	url, err := v2URL(matches[0].endpoint, opts)
+	if err != nil {
+		trace.add("the matching endpoint has no usable %s URL: %s", opts.Availability, err)
+		return nil, "", err
+	}
+	trace.add("selected %s", url)
+	return &matches[0], url, nil
+}
+
+// endpointTrace records the decisions made while matching endpoints, for V2EndpointURLWithTrace. A
+// nil *endpointTrace records nothing.
+type endpointTrace []string
+
+// add records a decision, if the trace is being kept.
+func (t *endpointTrace) add(format string, args ...interface{}) {
+	if t != nil {
+		*t = append(*t, fmt.Sprintf(format, args...))
+	}
+}
+
+// v2EndpointNotFound explains why no endpoint in the catalog matched opts: either there's no service
+// of the requested type at all, or there is but none of its endpoints satisfy the other criteria.
+func v2EndpointNotFound(catalog *tokens2.ServiceCatalog, opts gophercloud.EndpointOpts) error {
+	for _, entry := range catalog.Entries {
+		if opts.MatchType(entry.Type) {
+			return &gophercloud.EndpointNotFoundError{Opts: opts, Err: gophercloud.ErrNoEndpointForCriteria}
 		}
 	}
+	return &gophercloud.EndpointNotFoundError{Opts: opts, Err: gophercloud.ErrServiceNotInCatalog}
+}
 
-	// Report an error if there were no matching endpoints.
-	return "", gophercloud.ErrEndpointNotFound
+// v2URL extracts the URL with the requested Availability from a v2 Endpoint and validates it. If
+// the endpoint doesn't offer that Availability, the AvailabilityFallbacks are tried in turn.
+func v2URL(endpoint tokens2.Endpoint, opts gophercloud.EndpointOpts) (string, error) {
+	url, err := endpoint.URL(opts.Availability)
+	for _, fallback := range opts.AvailabilityFallbacks {
+		if err != tokens2.ErrEndpointURLMissing {
+			break
+		}
+		url, err = endpoint.URL(fallback)
+	}
+	if err != nil {
+		return "", err
+	}
+	return normalizeURL(url)
 }
 
 // V3EndpointURL discovers the endpoint URL for a specific service from a Catalog acquired
 // during the v3 identity service. The specified EndpointOpts are used to identify a unique,
 // unambiguous endpoint to return. It's an error both when multiple endpoints match the provided
-// criteria and when none do. The minimum that can be specified is a Type, but you will also often
-// need to specify a Name and/or a Region depending on what's available on your OpenStack
-// deployment.
+// criteria, in which case the error lists the candidates, and when none do, in which case it's a
+// *gophercloud.EndpointNotFoundError wrapping gophercloud.ErrServiceNotInCatalog if the catalog has
+// no service of the requested Type and gophercloud.ErrNoEndpointForCriteria if it does. The minimum that can be
+// specified is a Type, but you will also often need to specify a Name and/or a Region depending on
+// what's available on your OpenStack deployment. If no Availability is specified, the public
+// endpoint is chosen.
 func V3EndpointURL(catalog *tokens3.ServiceCatalog, opts gophercloud.EndpointOpts) (string, error) {
-	// Extract Endpoints from the catalog entries that match the requested Type, Interface,
-	// Name if provided, and Region if provided.
+	opts = defaultAvailability(opts)
+	endpoints, err := v3Endpoints(catalog, opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Report an error if the options were ambiguous.
+	if len(endpoints) > 1 {
+		return "", &ErrMultipleV3Endpoints{Opts: opts, Endpoints: endpoints}
+	}
+
+	// Extract the URL from the matching Endpoint.
+	for _, endpoint := range endpoints {
+		return normalizeURL(endpoint.URL)
+	}
+
+	// Report an error if there were no matching endpoints.
+	return "", v3EndpointNotFound(catalog, opts)
+}
+
+// V3EndpointURLs discovers every endpoint URL for a specific service from a Catalog acquired during
+// the v3 identity service. Unlike V3EndpointURL, it isn't an error for several endpoints to match
+// the provided EndpointOpts: the URLs of all of them are returned, in the order in which they appear
+// in the catalog, except that any tripped by opts.Breaker come last. It's still an error when no
+// endpoints match.
+func V3EndpointURLs(catalog *tokens3.ServiceCatalog, opts gophercloud.EndpointOpts) ([]string, error) {
+	opts = defaultAvailability(opts)
+	endpoints, err := v3Endpoints(catalog, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return nil, v3EndpointNotFound(catalog, opts)
+	}
+
+	urls := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		url, err := normalizeURL(endpoint.URL)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return opts.Breaker.Prioritize(urls), nil
+}
+
+// v3Endpoints extracts Endpoints from the catalog entries that match the requested Type, Interface,
+// Name or NamePrefix if provided, and Region if provided. Interfaces are compared
+// case-insensitively, and the Region may match either an endpoint's region or its region_id. If no
+// endpoint offers the requested Availability, the AvailabilityFallbacks are tried in turn. If
+// opts.DeduplicateURLs is set, endpoints whose URL repeats that of an earlier one are dropped.
+func v3Endpoints(catalog *tokens3.ServiceCatalog, opts gophercloud.EndpointOpts) ([]tokens3.Endpoint, error) {
+	endpoints, err := v3EndpointsWithAvailability(catalog, opts)
+	for _, fallback := range opts.AvailabilityFallbacks {
+		if err != nil || len(endpoints) > 0 {
+			break
+		}
+		opts.Availability = fallback
+		endpoints, err = v3EndpointsWithAvailability(catalog, opts)
+	}
+	if err != nil || !opts.DeduplicateURLs {
+		return endpoints, err
+	}
+
+	unique := make([]tokens3.Endpoint, 0, len(endpoints))
+	seen := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		// Endpoints without a valid URL are kept, so that the error is still reported.
+		if url, err := normalizeURL(endpoint.URL); err == nil {
+			if seen[url] {
+				continue
+			}
+			seen[url] = true
+		}
+		unique = append(unique, endpoint)
+	}
+	return unique, nil
+}
+
+// v3EndpointsWithAvailability extracts the Endpoints that match opts, ignoring its
+// AvailabilityFallbacks.
+func v3EndpointsWithAvailability(catalog *tokens3.ServiceCatalog, opts gophercloud.EndpointOpts) ([]tokens3.Endpoint, error) {
 	var endpoints = make([]tokens3.Endpoint, 0, 1)
 	for _, entry := range catalog.Entries {
-		if (entry.Type == opts.Type) && (opts.Name == "" || entry.Name == opts.Name) {
+		if opts.MatchType(entry.Type) && opts.MatchName(entry.Name) {
 			for _, endpoint := range entry.Endpoints {
-				if opts.Availability != gophercloud.AvailabilityAdmin &&
-					opts.Availability != gophercloud.AvailabilityPublic &&
-					opts.Availability != gophercloud.AvailabilityInternal {
-					return "", fmt.Errorf("Unexpected availability in endpoint query: %s", opts.Availability)
+				if !opts.Availability.IsValid() {
+					return nil, fmt.Errorf("Unexpected availability in endpoint query: %s", opts.Availability)
+				}
+				availability, err := endpoint.Availability()
+				if err != nil {
+					// The endpoint can't match any query, so there's no reason to fail the lookup.
+					continue
 				}
-				if (opts.Availability == gophercloud.Availability(endpoint.Interface)) &&
-					(opts.Region == "" || endpoint.Region == opts.Region) {
+				if (opts.Availability == availability) &&
+					(opts.Region == "" || endpoint.Region == opts.Region || endpoint.RegionID == opts.Region) {
 					endpoints = append(endpoints, endpoint)
 				}
 			}
 		}
 	}
+	return endpoints, nil
+}
 
-	// Report an error if the options were ambiguous.
-	if len(endpoints) > 1 {
-		return "", fmt.Errorf("Discovered %d matching endpoints: %#v", len(endpoints), endpoints)
+// v3EndpointNotFound explains why no endpoint in the catalog matched opts, as v2EndpointNotFound does.
+func v3EndpointNotFound(catalog *tokens3.ServiceCatalog, opts gophercloud.EndpointOpts) error {
+	for _, entry := range catalog.Entries {
+		if opts.MatchType(entry.Type) {
+			return &gophercloud.EndpointNotFoundError{Opts: opts, Err: gophercloud.ErrNoEndpointForCriteria}
+		}
 	}
+	return &gophercloud.EndpointNotFoundError{Opts: opts, Err: gophercloud.ErrServiceNotInCatalog}
+}
 
-	// Extract the URL from the matching Endpoint.
-	for _, endpoint := range endpoints {
-		return gophercloud.NormalizeURL(endpoint.URL), nil
+// normalizeURL validates an endpoint URL taken from a service catalog, then normalizes it.
+func normalizeURL(raw string) (string, error) {
+	if err := gophercloud.ValidateEndpointURL(raw); err != nil {
+		return "", err
 	}
+	return gophercloud.NormalizeURL(raw), nil
+}
 
-	// Report an error if there were no matching endpoints.
-	return "", gophercloud.ErrEndpointNotFound
+// defaultAvailability selects the public endpoint when the caller didn't specify an Availability.
+func defaultAvailability(opts gophercloud.EndpointOpts) gophercloud.EndpointOpts {
+	if opts.Availability == "" {
+		opts.Availability = gophercloud.AvailabilityPublic
+	}
+	return opts
 }
@@ -1,6 +1,7 @@
 package openstack
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -84,18 +85,43 @@ func TestV2EndpointNone(t *testing.T) {
 		Type:         "nope",
 		Availability: gophercloud.AvailabilityPublic,
 	})
-	th.CheckEquals(t, gophercloud.ErrEndpointNotFound, err)
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
 }
 
-func TestV2EndpointMultiple(t *testing.T) {
+func TestV2EndpointNoneForCriteria(t *testing.T) {
 	_, err := V2EndpointURL(&catalog2, gophercloud.EndpointOpts{
+		Type:   "same",
+		Region: "typo",
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+
+	_, err = V2EndpointURLs(&catalog2, gophercloud.EndpointOpts{
+		Type:   "same",
+		Region: "typo",
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+}
+
+func TestV2EndpointMultiple(t *testing.T) {
+	opts := gophercloud.EndpointOpts{
 		Type:         "same",
 		Region:       "same",
 		Availability: gophercloud.AvailabilityPublic,
-	})
+	}
+	_, err := V2EndpointURL(&catalog2, opts)
 	if !strings.HasPrefix(err.Error(), "Discovered 2 matching endpoints:") {
 		t.Errorf("Received unexpected error: %v", err)
 	}
+
+	multiple, ok := err.(*ErrMultipleEndpoints)
+	if !ok {
+		t.Fatalf("Expected an *ErrMultipleEndpoints, but got %#v", err)
+	}
+	th.CheckDeepEquals(t, opts, multiple.Opts)
+	th.CheckDeepEquals(t, []tokens2.Endpoint{
+		catalog2.Entries[0].Endpoints[0],
+		catalog2.Entries[1].Endpoints[0],
+	}, multiple.Endpoints)
 }
 
 func TestV2EndpointBadAvailability(t *testing.T) {
@@ -108,6 +134,157 @@ func TestV2EndpointBadAvailability(t *testing.T) {
 	th.CheckEquals(t, "Unexpected availability in endpoint query: wat", err.Error())
 }
 
+func TestV2EndpointDefaultAvailability(t *testing.T) {
+	actual, err := V2EndpointURL(&catalog2, gophercloud.EndpointOpts{
+		Type:   "same",
+		Name:   "same",
+		Region: "same",
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.correct.com/", actual)
+}
+
+func TestV2EndpointInvalidURL(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{PublicURL: "localhost:8774/v2/"},
+				},
+			},
+		},
+	}
+
+	_, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "compute",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.CheckEquals(t, gophercloud.ValidateEndpointURL("localhost:8774/v2/").Error(), err.Error())
+}
+
+func TestV2EndpointVersionID(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type: "volume",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{
+						PublicURL: "https://volume.com/v1/",
+						VersionID: "1",
+					},
+					tokens2.Endpoint{
+						PublicURL: "https://volume.com/v2/",
+						VersionID: "2",
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "volume",
+		VersionID:    "2",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://volume.com/v2/", actual)
+
+	_, err = V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "volume",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	if !strings.HasPrefix(err.Error(), "Discovered 2 matching endpoints:") {
+		t.Errorf("Received unexpected error: %v", err)
+	}
+}
+
+func TestV2EndpointTenantID(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type: "object-store",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{
+						PublicURL: "https://storage.com/v1/MossoCloudFS_1",
+						TenantID:  "MossoCloudFS_1",
+					},
+					tokens2.Endpoint{
+						PublicURL: "https://storage.com/v1/MossoCloudFS_2",
+						TenantID:  "MossoCloudFS_2",
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:     "object-store",
+		TenantID: "MossoCloudFS_2",
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://storage.com/v1/MossoCloudFS_2/", actual)
+}
+
+func TestV2CatalogEntry(t *testing.T) {
+	entry, err := V2CatalogEntry(&catalog2, gophercloud.EndpointOpts{Type: "same", Name: "different", Region: "different"})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, catalog2.Entries[1], *entry)
+
+	entry.Name = "changed"
+	th.CheckEquals(t, "different", catalog2.Entries[1].Name)
+	entry.Endpoints[0].Region = "changed"
+	th.CheckEquals(t, "same", catalog2.Entries[1].Endpoints[0].Region)
+
+	_, err = V2CatalogEntry(&catalog2, gophercloud.EndpointOpts{Type: "same", Region: "same"})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints, but got %#v", err)
+	}
+
+	_, err = V2CatalogEntry(&catalog2, gophercloud.EndpointOpts{Type: "nope"})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+
+	_, err = V2CatalogEntry(&catalog2, gophercloud.EndpointOpts{
+		Type:         "same",
+		Name:         "different",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityAdmin,
+	})
+	th.CheckEquals(t, tokens2.ErrEndpointURLMissing, err)
+}
+
+func TestV2EndpointURLs(t *testing.T) {
+	actual, err := V2EndpointURLs(&catalog2, gophercloud.EndpointOpts{
+		Type:         "same",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"https://public.correct.com/", "https://badname.com/"}, actual)
+}
+
+func TestV2EndpointURLsWithBreaker(t *testing.T) {
+	breaker := &gophercloud.EndpointBreaker{Threshold: 1}
+	breaker.RecordFailure("https://public.correct.com/")
+
+	actual, err := V2EndpointURLs(&catalog2, gophercloud.EndpointOpts{
+		Type:         "same",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityPublic,
+		Breaker:      breaker,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"https://badname.com/", "https://public.correct.com/"}, actual)
+}
+
+func TestV2EndpointURLsNone(t *testing.T) {
+	_, err := V2EndpointURLs(&catalog2, gophercloud.EndpointOpts{
+		Type:         "nope",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+}
+
 var catalog3 = tokens3.ServiceCatalog{
 	Entries: []tokens3.CatalogEntry{
 		tokens3.CatalogEntry{
@@ -179,6 +356,242 @@ var catalog3 = tokens3.ServiceCatalog{
 	},
 }
 
+func TestV2EndpointNamePrefix(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type:      "compute",
+				Name:      "cloudServersOpenStack",
+				Endpoints: []tokens2.Endpoint{tokens2.Endpoint{PublicURL: "https://next.compute.com/"}},
+			},
+			tokens2.CatalogEntry{
+				Type:      "compute",
+				Name:      "nova",
+				Endpoints: []tokens2.Endpoint{tokens2.Endpoint{PublicURL: "https://nova.compute.com/"}},
+			},
+		},
+	}
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", NamePrefix: "cloudServers"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://next.compute.com/", actual)
+
+	_, err = V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", Name: "cloudServers"})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+}
+
+func TestV2EndpointURLWithTrace(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type:      "volume",
+				Name:      "cinder",
+				Endpoints: []tokens2.Endpoint{tokens2.Endpoint{Region: "RegionOne", PublicURL: "https://volume.one.com/"}},
+			},
+			tokens2.CatalogEntry{
+				Type: "compute",
+				Name: "nova",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{Region: "RegionOne", PublicURL: "https://compute.one.com/"},
+					tokens2.Endpoint{Region: "RegionTwo", PublicURL: "https://compute.two.com/"},
+				},
+			},
+		},
+	}
+
+	opts := gophercloud.EndpointOpts{Type: "compute", Region: "RegionOne"}
+	url, trace, err := V2EndpointURLWithTrace(&catalog, opts)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://compute.one.com/", url)
+	th.CheckDeepEquals(t, []string{
+		`entry "cinder" excluded: type "volume" doesn't match "compute"`,
+		`entry "nova" matched type "compute"`,
+		`entry "nova" endpoint 0 matched in region "RegionOne"`,
+		`entry "nova" endpoint 1 excluded: region "RegionTwo" != "RegionOne"`,
+		`selected https://compute.one.com/`,
+	}, trace)
+
+	expected, err := V2EndpointURL(&catalog, opts)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, expected, url)
+
+	_, trace, err = V2EndpointURLWithTrace(&catalog, gophercloud.EndpointOpts{Type: "compute"})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints, but got %#v", err)
+	}
+	th.CheckEquals(t, "2 endpoints matched, so the choice is ambiguous", trace[len(trace)-1])
+
+	_, trace, err = V2EndpointURLWithTrace(&catalog, gophercloud.EndpointOpts{Type: "compute", Region: "RegionThree"})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+	th.CheckEquals(t, "no endpoints matched", trace[len(trace)-1])
+}
+
+func TestV2EndpointCaseInsensitive(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type:      "Compute",
+				Name:      "Nova",
+				Endpoints: []tokens2.Endpoint{tokens2.Endpoint{PublicURL: "https://nova.compute.com/"}},
+			},
+		},
+	}
+
+	_, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute"})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", Name: "nova", CaseInsensitive: true})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://nova.compute.com/", actual)
+}
+
+func TestV2EndpointAvailabilityFallbacks(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type:      "compute",
+				Endpoints: []tokens2.Endpoint{tokens2.Endpoint{PublicURL: "https://public.compute.com/"}},
+			},
+		},
+	}
+
+	_, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "compute",
+		Availability: gophercloud.AvailabilityInternal,
+	})
+	th.CheckEquals(t, tokens2.ErrEndpointURLMissing, err)
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:                  "compute",
+		Availability:          gophercloud.AvailabilityInternal,
+		AvailabilityFallbacks: []gophercloud.Availability{gophercloud.AvailabilityAdmin, gophercloud.AvailabilityPublic},
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.compute.com/", actual)
+}
+
+func TestV2EndpointDeduplicateURLs(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type: "compute",
+				Name: "nova",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{PublicURL: "https://compute.com/v2", InternalURL: "https://compute.com/v2"},
+				},
+			},
+			tokens2.CatalogEntry{
+				Type: "compute",
+				Name: "nova-duplicate",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{PublicURL: "https://compute.com/v2/", InternalURL: "https://internal.compute.com/v2/"},
+				},
+			},
+		},
+	}
+
+	_, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute"})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints without deduplication, but got %#v", err)
+	}
+
+	actual, err := V2EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", DeduplicateURLs: true})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://compute.com/v2/", actual)
+
+	urls, err := V2EndpointURLs(&catalog, gophercloud.EndpointOpts{Type: "compute", DeduplicateURLs: true})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"https://compute.com/v2/"}, urls)
+
+	// The internal URLs differ, so the endpoints are still distinct.
+	_, err = V2EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:            "compute",
+		Availability:    gophercloud.AvailabilityInternal,
+		DeduplicateURLs: true,
+	})
+	if _, ok := err.(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints for distinct internal URLs, but got %#v", err)
+	}
+}
+
+func TestSelectEndpoint(t *testing.T) {
+	catalog := tokens2.ServiceCatalog{
+		Entries: []tokens2.CatalogEntry{
+			tokens2.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens2.Endpoint{
+					tokens2.Endpoint{Region: "east", PublicURL: "https://east.compute.com/"},
+					tokens2.Endpoint{Region: "west", PublicURL: "https://west.compute.com/", InternalURL: "https://west.internal/"},
+					tokens2.Endpoint{Region: "north", PublicURL: "https://north.compute.com/"},
+					tokens2.Endpoint{Region: "west", PublicURL: "https://west2.compute.com/"},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		opts       gophercloud.EndpointOpts
+		preference []string
+		expected   string
+	}{
+		// Without a preference, the first match in the catalog wins.
+		{gophercloud.EndpointOpts{Type: "compute"}, nil, "https://east.compute.com/"},
+		// Preferred regions are tried in order, and ties go to the first in the catalog.
+		{gophercloud.EndpointOpts{Type: "compute"}, []string{"south", "west", "east"}, "https://west.compute.com/"},
+		// Regions missing from the preference rank after those present in it.
+		{gophercloud.EndpointOpts{Type: "compute"}, []string{"north"}, "https://north.compute.com/"},
+		// An explicit Region is a requirement.
+		{gophercloud.EndpointOpts{Type: "compute", Region: "east"}, []string{"west"}, "https://east.compute.com/"},
+		// Endpoints lacking the requested availability are passed over.
+		{gophercloud.EndpointOpts{Type: "compute", Availability: gophercloud.AvailabilityInternal}, []string{"east"}, "https://west.internal/"},
+		// Region preference outranks availability fallbacks.
+		{gophercloud.EndpointOpts{
+			Type:                  "compute",
+			Availability:          gophercloud.AvailabilityInternal,
+			AvailabilityFallbacks: []gophercloud.Availability{gophercloud.AvailabilityPublic},
+		}, []string{"north", "west"}, "https://north.compute.com/"},
+	}
+
+	for _, c := range cases {
+		actual, err := SelectEndpoint(&catalog, c.opts, c.preference)
+		th.AssertNoErr(t, err)
+		th.CheckEquals(t, c.expected, actual)
+	}
+
+	_, err := SelectEndpoint(&catalog, gophercloud.EndpointOpts{Type: "volume"}, nil)
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+
+	_, err = SelectEndpoint(&catalog, gophercloud.EndpointOpts{Type: "compute", Region: "south"}, nil)
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+
+	_, err = SelectEndpoint(&catalog, gophercloud.EndpointOpts{Type: "compute", Availability: gophercloud.AvailabilityAdmin}, nil)
+	th.CheckEquals(t, tokens2.ErrEndpointURLMissing, err)
+}
+
+func TestResolveEndpoints(t *testing.T) {
+	urls, err := ResolveEndpoints(&catalog2, gophercloud.EndpointOpts{Region: "same"}, []string{"different"})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, map[string]string{"different": "https://badtype.com/+badname"}, urls)
+}
+
+func TestResolveEndpointsFailures(t *testing.T) {
+	urls, err := ResolveEndpoints(&catalog2, gophercloud.EndpointOpts{Region: "same"}, []string{"different", "same", "nope"})
+	th.CheckDeepEquals(t, map[string]string{"different": "https://badtype.com/+badname"}, urls)
+
+	resolveErr, ok := err.(*ErrResolveEndpoints)
+	if !ok {
+		t.Fatalf("Expected an *ErrResolveEndpoints, but got %#v", err)
+	}
+	th.CheckEquals(t, 2, len(resolveErr.Errors))
+	th.CheckEquals(t, true, errors.Is(resolveErr.Errors["nope"], gophercloud.ErrServiceNotInCatalog))
+	if _, ok := resolveErr.Errors["same"].(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleEndpoints for the same type, but got %#v", resolveErr.Errors["same"])
+	}
+	if !strings.HasPrefix(err.Error(), "Unable to resolve endpoints for 2 service types: nope: No endpoint found for type=nope region=same availability=public. "+gophercloud.ErrServiceNotInCatalog.Error()+"; same: Discovered 2 matching endpoints:") {
+		t.Errorf("Received unexpected error: %v", err)
+	}
+}
+
 func TestV3EndpointExact(t *testing.T) {
 	expectedURLs := map[gophercloud.Availability]string{
 		gophercloud.AvailabilityPublic:   "https://public.correct.com/",
@@ -203,7 +616,15 @@ func TestV3EndpointNone(t *testing.T) {
 		Type:         "nope",
 		Availability: gophercloud.AvailabilityPublic,
 	})
-	th.CheckEquals(t, gophercloud.ErrEndpointNotFound, err)
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+}
+
+func TestV3EndpointNoneForCriteria(t *testing.T) {
+	_, err := V3EndpointURL(&catalog3, gophercloud.EndpointOpts{
+		Type:   "same",
+		Region: "typo",
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
 }
 
 func TestV3EndpointMultiple(t *testing.T) {
@@ -215,6 +636,13 @@ func TestV3EndpointMultiple(t *testing.T) {
 	if !strings.HasPrefix(err.Error(), "Discovered 2 matching endpoints:") {
 		t.Errorf("Received unexpected error: %v", err)
 	}
+
+	multiple, ok := err.(*ErrMultipleV3Endpoints)
+	if !ok {
+		t.Fatalf("Expected an *ErrMultipleV3Endpoints, but got %#v", err)
+	}
+	th.CheckEquals(t, "1", multiple.Endpoints[0].ID)
+	th.CheckEquals(t, "5", multiple.Endpoints[1].ID)
 }
 
 func TestV3EndpointBadAvailability(t *testing.T) {
@@ -226,3 +654,127 @@ func TestV3EndpointBadAvailability(t *testing.T) {
 	})
 	th.CheckEquals(t, "Unexpected availability in endpoint query: wat", err.Error())
 }
+
+func TestV3EndpointDefaultAvailability(t *testing.T) {
+	actual, err := V3EndpointURL(&catalog3, gophercloud.EndpointOpts{
+		Type:   "same",
+		Name:   "same",
+		Region: "same",
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.correct.com/", actual)
+}
+
+func TestV3EndpointURLs(t *testing.T) {
+	actual, err := V3EndpointURLs(&catalog3, gophercloud.EndpointOpts{
+		Type:         "same",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"https://public.correct.com/", "https://badname.com/"}, actual)
+}
+
+func TestV3EndpointURLsWithBreaker(t *testing.T) {
+	breaker := &gophercloud.EndpointBreaker{Threshold: 1}
+	breaker.RecordFailure("https://public.correct.com/")
+
+	actual, err := V3EndpointURLs(&catalog3, gophercloud.EndpointOpts{
+		Type:         "same",
+		Region:       "same",
+		Availability: gophercloud.AvailabilityPublic,
+		Breaker:      breaker,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"https://badname.com/", "https://public.correct.com/"}, actual)
+}
+
+func TestV3EndpointURLsNone(t *testing.T) {
+	_, err := V3EndpointURLs(&catalog3, gophercloud.EndpointOpts{
+		Type:         "nope",
+		Availability: gophercloud.AvailabilityPublic,
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrServiceNotInCatalog))
+}
+
+func TestV3EndpointInterfaceAndRegionID(t *testing.T) {
+	catalog := tokens3.ServiceCatalog{
+		Entries: []tokens3.CatalogEntry{
+			tokens3.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens3.Endpoint{
+					tokens3.Endpoint{ID: "1", RegionID: "RegionOne", Interface: "Public", URL: "https://public.correct.com/"},
+					tokens3.Endpoint{ID: "2", RegionID: "RegionOne", Interface: "ADMIN", URL: "https://admin.correct.com/"},
+					tokens3.Endpoint{ID: "3", RegionID: "RegionOne", Interface: "unknown", URL: "https://unknown.com/"},
+				},
+			},
+		},
+	}
+
+	actual, err := V3EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", Region: "RegionOne"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.correct.com/", actual)
+
+	actual, err = V3EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "compute",
+		Region:       "RegionOne",
+		Availability: gophercloud.AvailabilityAdmin,
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://admin.correct.com/", actual)
+}
+
+func TestV3EndpointAvailabilityFallbacks(t *testing.T) {
+	catalog := tokens3.ServiceCatalog{
+		Entries: []tokens3.CatalogEntry{
+			tokens3.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens3.Endpoint{
+					tokens3.Endpoint{ID: "1", Interface: "public", URL: "https://public.compute.com/"},
+				},
+			},
+		},
+	}
+
+	_, err := V3EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:         "compute",
+		Availability: gophercloud.AvailabilityInternal,
+	})
+	th.CheckEquals(t, true, errors.Is(err, gophercloud.ErrNoEndpointForCriteria))
+
+	actual, err := V3EndpointURL(&catalog, gophercloud.EndpointOpts{
+		Type:                  "compute",
+		Availability:          gophercloud.AvailabilityInternal,
+		AvailabilityFallbacks: []gophercloud.Availability{gophercloud.AvailabilityPublic},
+	})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.compute.com/", actual)
+}
+
+func TestV3EndpointDeduplicateURLs(t *testing.T) {
+	catalog := tokens3.ServiceCatalog{
+		Entries: []tokens3.CatalogEntry{
+			tokens3.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens3.Endpoint{
+					tokens3.Endpoint{ID: "1", Interface: "public", URL: "https://compute.com/v2"},
+				},
+			},
+			tokens3.CatalogEntry{
+				Type: "compute",
+				Endpoints: []tokens3.Endpoint{
+					tokens3.Endpoint{ID: "2", Interface: "public", URL: "https://compute.com/v2/"},
+				},
+			},
+		},
+	}
+
+	_, err := V3EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute"})
+	if _, ok := err.(*ErrMultipleV3Endpoints); !ok {
+		t.Errorf("Expected an *ErrMultipleV3Endpoints without deduplication, but got %#v", err)
+	}
+
+	actual, err := V3EndpointURL(&catalog, gophercloud.EndpointOpts{Type: "compute", DeduplicateURLs: true})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://compute.com/v2/", actual)
+}
@@ -0,0 +1,96 @@
+package openstack
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
+	tokens3 "github.com/rackspace/gophercloud/openstack/identity/v3/tokens"
+)
+
+// ErrNoIdentityEndpoint is returned by AuthenticatedClient if the AuthOptions don't specify an
+// IdentityEndpoint to authenticate against.
+var ErrNoIdentityEndpoint = errors.New("You must provide an IdentityEndpoint in your AuthOptions.")
+
+// ErrTenantNotFound is returned by AuthenticatedClient when SuggestTenants is set and the identity
+// service rejects authentication because the user can't access a tenant with the requested
+// TenantName. Err is the identity service's original rejection.
+type ErrTenantNotFound struct {
+	// TenantName is the name that was requested.
+	TenantName string
+
+	// Available are the names of the tenants the user can access, sorted.
+	Available []string
+
+	Err error
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrTenantNotFound) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("Tenant %q was not found, and the user can't access any tenants.", e.TenantName)
+	}
+	return fmt.Sprintf("Tenant %q was not found. The user can access: %s", e.TenantName, strings.Join(e.Available, ", "))
+}
+
+// Unwrap returns the identity service's original rejection.
+func (e *ErrTenantNotFound) Unwrap() error {
+	return e.Err
+}
+
+// ErrMultipleEndpoints is returned by V2EndpointURL when more than one endpoint in the service
+// catalog matches the provided EndpointOpts. Type-assert to it to enumerate the candidates and apply
+// your own tiebreaker.
+type ErrMultipleEndpoints struct {
+	// Opts are the criteria that all of the Endpoints matched.
+	Opts gophercloud.EndpointOpts
+
+	// Endpoints are the matching endpoints, in the order in which they appear in the catalog.
+	Endpoints []tokens2.Endpoint
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrMultipleEndpoints) Error() string {
+	return fmt.Sprintf("Discovered %d matching endpoints: %+v", len(e.Endpoints), e.Endpoints)
+}
+
+// ErrMultipleV3Endpoints is returned by V3EndpointURL when more than one endpoint in the service
+// catalog matches the provided EndpointOpts. Type-assert to it to enumerate the candidates and apply
+// your own tiebreaker.
+type ErrMultipleV3Endpoints struct {
+	// Opts are the criteria that all of the Endpoints matched.
+	Opts gophercloud.EndpointOpts
+
+	// Endpoints are the matching endpoints, in the order in which they appear in the catalog.
+	Endpoints []tokens3.Endpoint
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrMultipleV3Endpoints) Error() string {
+	return fmt.Sprintf("Discovered %d matching endpoints: %+v", len(e.Endpoints), e.Endpoints)
+}
+
+// ErrResolveEndpoints is returned by ResolveEndpoints when the URLs for one or more service types
+// couldn't be resolved. Errors maps each of those service types to the reason it failed, such as
+// a *gophercloud.EndpointNotFoundError or an *ErrMultipleEndpoints.
+type ErrResolveEndpoints struct {
+	Errors map[string]error
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrResolveEndpoints) Error() string {
+	types := make([]string, 0, len(e.Errors))
+	for serviceType := range e.Errors {
+		types = append(types, serviceType)
+	}
+	sort.Strings(types)
+
+	failures := make([]string, 0, len(types))
+	for _, serviceType := range types {
+		failures = append(failures, fmt.Sprintf("%s: %s", serviceType, e.Errors[serviceType]))
+	}
+	return fmt.Sprintf("Unable to resolve endpoints for %d service types: %s", len(types), strings.Join(failures, "; "))
+}
@@ -0,0 +1,88 @@
+package tokens
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/rackspace/gophercloud"
+)
+
+// TokenCache remembers the Token and ServiceCatalog acquired with a particular set of AuthOptions,
+// so that they can be reused rather than authenticating again. Entries are evicted once their Token
+// has expired. The zero value is an empty cache ready to use, and it's safe for concurrent use.
+type TokenCache struct {
+	mut     sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	token   *Token
+	catalog *ServiceCatalog
+}
+
+// cacheKey derives a cache key from AuthOptions. The options are hashed so that credentials aren't
+// kept around in clear text.
+func cacheKey(opts gophercloud.AuthOptions) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", opts)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the Token and ServiceCatalog stored for the given AuthOptions. The final return value
+// is false if there's no entry for them, or if its Token has expired.
+func (c *TokenCache) Get(opts gophercloud.AuthOptions) (*Token, *ServiceCatalog, bool) {
+	key := cacheKey(opts)
+
+	c.mut.RLock()
+	entry, ok := c.entries[key]
+	c.mut.RUnlock()
+
+	if !ok {
+		return nil, nil, false
+	}
+	if entry.token.IsExpired() {
+		c.mut.Lock()
+		if current, ok := c.entries[key]; ok && current.token == entry.token {
+			delete(c.entries, key)
+		}
+		c.mut.Unlock()
+		return nil, nil, false
+	}
+	return entry.token, entry.catalog, true
+}
+
+// Set stores a Token and its ServiceCatalog for the given AuthOptions, replacing any previous entry.
+func (c *TokenCache) Set(opts gophercloud.AuthOptions, token *Token, catalog *ServiceCatalog) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]cacheEntry)
+	}
+	c.entries[cacheKey(opts)] = cacheEntry{token: token, catalog: catalog}
+}
+
+// GetOrCreate returns the cached Token and ServiceCatalog for the given AuthOptions. On a miss, it
+// calls create to authenticate, usually by wrapping a call to Create, and caches the result.
+// Concurrent misses for the same options may each authenticate; the last one to finish wins.
+func (c *TokenCache) GetOrCreate(opts gophercloud.AuthOptions, create func() CreateResult) (*Token, *ServiceCatalog, error) {
+	if token, catalog, ok := c.Get(opts); ok {
+		return token, catalog, nil
+	}
+
+	result := create()
+
+	token, err := result.ExtractToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	catalog, err := result.ExtractServiceCatalog()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.Set(opts, token, catalog)
+	return token, catalog, nil
+}
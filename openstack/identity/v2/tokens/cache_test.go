@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+	th "github.com/rackspace/gophercloud/testhelper"
+	"github.com/rackspace/gophercloud/testhelper/client"
+)
+
+func TestTokenCacheGetAndSet(t *testing.T) {
+	var cache TokenCache
+	opts := gophercloud.AuthOptions{Username: "me", Password: "swordfish"}
+	other := gophercloud.AuthOptions{Username: "me", Password: "opensesame"}
+
+	_, _, ok := cache.Get(opts)
+	th.CheckEquals(t, false, ok)
+
+	token := &Token{ID: "aaaabbbbccccdddd", ExpiresAt: time.Now().Add(time.Hour)}
+	cache.Set(opts, token, ExpectedServiceCatalog)
+
+	actualToken, actualCatalog, ok := cache.Get(opts)
+	th.CheckEquals(t, true, ok)
+	th.CheckEquals(t, token, actualToken)
+	th.CheckEquals(t, ExpectedServiceCatalog, actualCatalog)
+
+	_, _, ok = cache.Get(other)
+	th.CheckEquals(t, false, ok)
+}
+
+func TestTokenCacheEvictsExpiredTokens(t *testing.T) {
+	var cache TokenCache
+	opts := gophercloud.AuthOptions{Username: "me", Password: "swordfish"}
+
+	cache.Set(opts, &Token{ID: "aaaabbbbccccdddd", ExpiresAt: time.Now().Add(-time.Minute)}, ExpectedServiceCatalog)
+
+	_, _, ok := cache.Get(opts)
+	th.CheckEquals(t, false, ok)
+	th.CheckEquals(t, 0, len(cache.entries))
+}
+
+func TestTokenCacheGetOrCreate(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenPost(t, "")
+
+	var cache TokenCache
+	opts := gophercloud.AuthOptions{Username: "me", Password: "swordfish"}
+	created := 0
+	create := func() CreateResult {
+		created++
+		return Create(client.ServiceClient(), WrapOptions(opts))
+	}
+
+	// The fixture's token expired long ago, so seed the cache with a fresh one first.
+	cache.Set(opts, &Token{ID: "cached", ExpiresAt: time.Now().Add(time.Hour)}, ExpectedServiceCatalog)
+	token, _, err := cache.GetOrCreate(opts, create)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "cached", token.ID)
+	th.CheckEquals(t, 0, created)
+
+	token, catalog, err := cache.GetOrCreate(gophercloud.AuthOptions{Username: "me", Password: "opensesame"}, create)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, ExpectedToken.ID, token.ID)
+	th.CheckDeepEquals(t, ExpectedServiceCatalog, catalog)
+	th.CheckEquals(t, 1, created)
+}
@@ -3,6 +3,10 @@ package tokens
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
 )
 
 var (
@@ -23,8 +27,65 @@ var (
 
 	// ErrPasswordRequired is returned if you don't provide a password.
 	ErrPasswordRequired = errors.New("Please supply a Password in your AuthOptions.")
+
+	// ErrCredentialsRequired is returned if you provide neither a Username and Password nor a TokenID.
+	ErrCredentialsRequired = errors.New("You must provide either username/password or tenantID/token values.")
+
+	// ErrTrustIDWithTenant is returned if you provide a TrustID along with a TenantID or TenantName.
+	ErrTrustIDWithTenant = errors.New("A TrustID determines the tenant, so it can't be combined with a TenantID or TenantName.")
+
+	// ErrTokenNotFound is returned by Get if the identity service doesn't recognize the token,
+	// usually because it has expired or been revoked.
+	ErrTokenNotFound = errors.New("The token was not found or has expired.")
+
+	// ErrTokenIDRequired is returned by Get, and reported by ValidateBatch, for an empty token ID,
+	// which would otherwise address the tokens collection rather than a token.
+	ErrTokenIDRequired = errors.New("A token ID is required to validate a token.")
+
+	// ErrEndpointURLMissing is returned by Endpoint.URL if the endpoint doesn't offer a URL with the
+	// requested availability.
+	ErrEndpointURLMissing = errors.New("The endpoint doesn't offer a URL with the requested availability.")
 )
 
 func unacceptedAttributeErr(attribute string) error {
 	return fmt.Errorf("The base Identity V2 API does not accept authentication by %s", attribute)
 }
+
+func reservedHeaderErr(header string) error {
+	return fmt.Errorf("The %s header is set by gophercloud, so it can't be provided in ExtraHeaders", header)
+}
+
+// ErrDecodeServiceCatalog is returned by ExtractServiceCatalog when the catalog in the response
+// doesn't have the expected shape. Problems describes each attribute that couldn't be decoded,
+// prefixed with its path within the response, such as "access.serviceCatalog[0].endpoints[1].region".
+type ErrDecodeServiceCatalog struct {
+	Problems []string
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrDecodeServiceCatalog) Error() string {
+	return fmt.Sprintf("Unable to decode the service catalog: %s", strings.Join(e.Problems, "; "))
+}
+
+// decodeServiceCatalogErr converts the error mapstructure reports for a catalog into an
+// *ErrDecodeServiceCatalog with one problem per attribute. Other errors are returned unchanged.
+func decodeServiceCatalogErr(err error) error {
+	decodeErr, ok := err.(*mapstructure.Error)
+	if !ok {
+		return err
+	}
+
+	problems := make([]string, 0, len(decodeErr.Errors))
+	for _, problem := range decodeErr.Errors {
+		// mapstructure reports problems as "'path' message" or "'path': message".
+		if strings.HasPrefix(problem, "'") {
+			if end := strings.Index(problem[1:], "'"); end >= 0 {
+				path, message := problem[1:end+1], problem[end+2:]
+				problem = path + ": " + strings.TrimLeft(message, ": ")
+			}
+		}
+		problems = append(problems, problem)
+	}
+	sort.Strings(problems)
+	return &ErrDecodeServiceCatalog{Problems: problems}
+}
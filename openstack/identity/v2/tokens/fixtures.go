@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/rackspace/gophercloud"
 	"github.com/rackspace/gophercloud/openstack/identity/v2/tenants"
 	th "github.com/rackspace/gophercloud/testhelper"
 )
@@ -16,12 +17,15 @@ import (
 var ExpectedToken = &Token{
 	ID:        "aaaabbbbccccdddd",
 	ExpiresAt: time.Date(2014, time.January, 31, 15, 30, 58, 0, time.UTC),
+	IssuedAt:  time.Date(2014, time.January, 30, 15, 30, 58, 0, time.UTC),
+	AuditIDs:  []string{"VcxU2JYqT8OzfUVvrjEITQ", "qNUTIJntTzO1-XUk5STybw"},
 	Tenant: tenants.Tenant{
 		ID:          "fc394f2ab2df4114bde39905f800dc57",
 		Name:        "test",
 		Description: "There are many tenants. This one is yours.",
 		Enabled:     true,
 	},
+	Scope: gophercloud.TokenScopeProject,
 }
 
 // ExpectedServiceCatalog is the service catalog that should be parsed from TokenCreationResponse.
@@ -54,6 +58,17 @@ var ExpectedServiceCatalog = &ServiceCatalog{
 	},
 }
 
+// ExpectedUser is the user that should be parsed from TokenCreationResponse.
+var ExpectedUser = &User{
+	ID:       "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+	Name:     "me",
+	Username: "me",
+	Roles: []Role{
+		Role{ID: "9fe2ff9ee4384b1894a90878d3e92bab", Name: "admin"},
+		Role{ID: "2ad7b0a1b6e84ce5a2b5e4bb1b8a3c4d", Name: "member"},
+	},
+}
+
 // TokenCreationResponse is a JSON response that contains ExpectedToken and ExpectedServiceCatalog.
 const TokenCreationResponse = `
 {
@@ -62,6 +77,7 @@ const TokenCreationResponse = `
 			"issued_at": "2014-01-30T15:30:58.000000Z",
 			"expires": "2014-01-31T15:30:58Z",
 			"id": "aaaabbbbccccdddd",
+			"audit_ids": ["VcxU2JYqT8OzfUVvrjEITQ", "qNUTIJntTzO1-XUk5STybw"],
 			"tenant": {
 				"description": "There are many tenants. This one is yours.",
 				"enabled": true,
@@ -69,6 +85,15 @@ const TokenCreationResponse = `
 				"name": "test"
 			}
 		},
+		"user": {
+			"id": "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+			"name": "me",
+			"username": "me",
+			"roles": [
+				{ "id": "9fe2ff9ee4384b1894a90878d3e92bab", "name": "admin" },
+				{ "id": "2ad7b0a1b6e84ce5a2b5e4bb1b8a3c4d", "name": "member" }
+			]
+		},
 		"serviceCatalog": [
 			{
 				"endpoints": [
@@ -99,6 +124,9 @@ const TokenCreationResponse = `
 }
 `
 
+// ExpectedRequestID is the request ID reported by the handler installed by HandleTokenPost.
+const ExpectedRequestID = "req-5f2c9e2a-8d0b-4f3e-9c55-6a1f1a0f4d2e"
+
 // HandleTokenPost expects a POST against a /tokens handler, ensures that the request body has been
 // constructed properly given certain auth options, and returns the result.
 func HandleTokenPost(t *testing.T, requestJSON string) {
@@ -110,19 +138,84 @@ func HandleTokenPost(t *testing.T, requestJSON string) {
 			th.TestJSONRequest(t, r, requestJSON)
 		}
 
+		w.Header().Set("X-Openstack-Request-Id", ExpectedRequestID)
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprintf(w, TokenCreationResponse)
 	})
 }
 
-// IsSuccessful ensures that a CreateResult was successful and contains the correct token and
+// IsSuccessful ensures that a CreateResult was successful and contains the correct token, user, and
 // service catalog.
 func IsSuccessful(t *testing.T, result CreateResult) {
 	token, err := result.ExtractToken()
 	th.AssertNoErr(t, err)
+	if token.AuthenticatedAt.IsZero() {
+		t.Errorf("Expected the token to record when it was authenticated")
+	}
+	token.AuthenticatedAt = time.Time{}
 	th.CheckDeepEquals(t, ExpectedToken, token)
 
 	serviceCatalog, err := result.ExtractServiceCatalog()
 	th.AssertNoErr(t, err)
 	th.CheckDeepEquals(t, ExpectedServiceCatalog, serviceCatalog)
+
+	user, err := result.ExtractUser()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, ExpectedUser, user)
+}
+
+// TokenGetResponse is a JSON response to a token validation request for the token in ExpectedToken.
+const TokenGetResponse = `
+{
+	"access": {
+		"token": {
+			"issued_at": "2014-01-30T15:30:58.000000Z",
+			"expires": "2014-01-31T15:30:58Z",
+			"id": "aaaabbbbccccdddd",
+			"audit_ids": ["VcxU2JYqT8OzfUVvrjEITQ", "qNUTIJntTzO1-XUk5STybw"],
+			"tenant": {
+				"description": "There are many tenants. This one is yours.",
+				"enabled": true,
+				"id": "fc394f2ab2df4114bde39905f800dc57",
+				"name": "test"
+			}
+		},
+		"user": {
+			"id": "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+			"name": "me",
+			"username": "me",
+			"roles": [
+				{ "id": "9fe2ff9ee4384b1894a90878d3e92bab", "name": "admin" },
+				{ "id": "2ad7b0a1b6e84ce5a2b5e4bb1b8a3c4d", "name": "member" }
+			]
+		}
+	}
+}
+`
+
+// HandleTokenGet expects a GET against a /tokens/{tokenID} handler and returns TokenGetResponse.
+func HandleTokenGet(t *testing.T, tokenID string) {
+	th.Mux.HandleFunc("/tokens/"+tokenID, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		th.TestHeader(t, r, "Accept", "application/json")
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, TokenGetResponse)
+	})
+}
+
+// HandleTokenGetNotFound expects a GET against a /tokens/{tokenID} handler and responds with a 404.
+func HandleTokenGetNotFound(t *testing.T, tokenID string) {
+	th.Mux.HandleFunc("/tokens/"+tokenID, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "GET")
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+// HandleTokenDelete expects a DELETE against a /tokens/{tokenID} handler and responds with a 204.
+func HandleTokenDelete(t *testing.T, tokenID string) {
+	th.Mux.HandleFunc("/tokens/"+tokenID, func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNoContent)
+	})
 }
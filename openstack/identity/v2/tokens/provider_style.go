@@ -0,0 +1,91 @@
+package tokens
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ProviderStyle identifies the family of cloud that issued a service catalog, so that code handling
+// several can adjust for their differences, such as Rackspace's authentication by API key and its
+// own service names.
+type ProviderStyle string
+
+const (
+	// ProviderStyleUnknown means the catalog offered nothing to go on, usually because it's empty.
+	ProviderStyleUnknown ProviderStyle = "unknown"
+
+	// ProviderStyleOpenStack means the catalog looks like that of a vanilla OpenStack deployment.
+	ProviderStyleOpenStack ProviderStyle = "openstack"
+
+	// ProviderStyleRackspace means the catalog was issued by the Rackspace Cloud.
+	ProviderStyleRackspace ProviderStyle = "rackspace"
+)
+
+// rackspaceServiceNames are the names under which the Rackspace Cloud lists its services, as opposed
+// to the project names, such as "nova" and "swift", that OpenStack deployments customarily use.
+var rackspaceServiceNames = map[string]bool{
+	"cloudServersOpenStack": true,
+	"cloudFiles":            true,
+	"cloudFilesCDN":         true,
+	"cloudBlockStorage":     true,
+	"cloudNetworks":         true,
+	"cloudOrchestration":    true,
+	"cloudImages":           true,
+	"cloudDatabases":        true,
+	"cloudLoadBalancers":    true,
+	"cloudDNS":              true,
+	"cloudMonitoring":       true,
+	"rackCDN":               true,
+}
+
+// rackspaceHostSuffixes are the domains that host the Rackspace Cloud's endpoints.
+var rackspaceHostSuffixes = []string{".rackspacecloud.com", ".rackcdn.com"}
+
+// DetectProviderStyle guesses which family of cloud issued the catalog. It reports
+// ProviderStyleRackspace if any of the following holds, and ProviderStyleOpenStack otherwise:
+//
+//   - a service's type has the "rax:" prefix Rackspace gives its proprietary services, such as
+//     "rax:dns" or "rax:load-balancer";
+//   - a service has one of the names the Rackspace Cloud uses, such as "cloudServersOpenStack" or
+//     "cloudFiles";
+//   - an endpoint's public, internal or admin URL is hosted under rackspacecloud.com or rackcdn.com.
+//
+// A catalog without any entries yields ProviderStyleUnknown. It's a heuristic: a private cloud that
+// mimics Rackspace's names is reported as Rackspace.
+func DetectProviderStyle(catalog *ServiceCatalog) ProviderStyle {
+	if catalog == nil || len(catalog.Entries) == 0 {
+		return ProviderStyleUnknown
+	}
+
+	for _, entry := range catalog.Entries {
+		if strings.HasPrefix(entry.Type, "rax:") || rackspaceServiceNames[entry.Name] {
+			return ProviderStyleRackspace
+		}
+		for _, endpoint := range entry.Endpoints {
+			for _, rawURL := range []string{endpoint.PublicURL, endpoint.InternalURL, endpoint.AdminURL} {
+				if isRackspaceHost(rawURL) {
+					return ProviderStyleRackspace
+				}
+			}
+		}
+	}
+	return ProviderStyleOpenStack
+}
+
+// isRackspaceHost reports whether rawURL is hosted in one of the Rackspace Cloud's domains.
+func isRackspaceHost(rawURL string) bool {
+	if rawURL == "" {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, suffix := range rackspaceHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package tokens
+
+import (
+	"testing"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestDetectProviderStyle(t *testing.T) {
+	th.CheckEquals(t, ProviderStyleUnknown, DetectProviderStyle(nil))
+	th.CheckEquals(t, ProviderStyleUnknown, DetectProviderStyle(&ServiceCatalog{}))
+
+	vanilla := &ServiceCatalog{Entries: []CatalogEntry{
+		CatalogEntry{Type: "compute", Name: "nova", Endpoints: []Endpoint{
+			Endpoint{PublicURL: "https://compute.example.com/v2/"},
+		}},
+	}}
+	th.CheckEquals(t, ProviderStyleOpenStack, DetectProviderStyle(vanilla))
+
+	byType := &ServiceCatalog{Entries: []CatalogEntry{CatalogEntry{Type: "rax:dns", Name: "dns"}}}
+	th.CheckEquals(t, ProviderStyleRackspace, DetectProviderStyle(byType))
+
+	byName := &ServiceCatalog{Entries: []CatalogEntry{CatalogEntry{Type: "compute", Name: "cloudServersOpenStack"}}}
+	th.CheckEquals(t, ProviderStyleRackspace, DetectProviderStyle(byName))
+
+	byHost := &ServiceCatalog{Entries: []CatalogEntry{
+		CatalogEntry{Type: "object-store", Name: "swift", Endpoints: []Endpoint{
+			Endpoint{InternalURL: "https://snet-storage101.iad3.clouddrive.com/v1/", PublicURL: "https://IAD.servers.api.rackspacecloud.com/v2/"},
+		}},
+	}}
+	th.CheckEquals(t, ProviderStyleRackspace, DetectProviderStyle(byHost))
+}
@@ -1,7 +1,10 @@
 package tokens
 
 import (
-	"fmt"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/rackspace/gophercloud"
 )
@@ -14,6 +17,16 @@ type AuthOptionsBuilder interface {
 	ToTokenCreateMap() (map[string]interface{}, error)
 }
 
+// AuthHeadersBuilder may be implemented by an AuthOptionsBuilder that needs additional headers to be
+// sent with the Create request.
+type AuthHeadersBuilder interface {
+	// ToTokenCreateHeaders returns the headers to add to the Create request.
+	ToTokenCreateHeaders() (map[string]string, error)
+}
+
+// reservedHeaders are set by ProviderClient itself, so they can't be supplied as ExtraHeaders.
+var reservedHeaders = []string{"Accept", "Content-Length", "Content-Type", "X-Auth-Token"}
+
 // AuthOptions wraps a gophercloud AuthOptions in order to adhere to the AuthOptionsBuilder
 // interface.
 type AuthOptions struct {
@@ -25,41 +38,86 @@ func WrapOptions(original gophercloud.AuthOptions) AuthOptions {
 	return AuthOptions{AuthOptions: original}
 }
 
-// ToTokenCreateMap converts AuthOptions into nested maps that can be serialized into a JSON
-// request.
-func (auth AuthOptions) ToTokenCreateMap() (map[string]interface{}, error) {
+// Validate checks that the AuthOptions describe a set of credentials that the v2 identity service
+// accepts, without contacting it. Either a Username and Password or a TokenID must be provided; if
+// both are, the TokenID is used and the Username and Password are ignored. Attributes that only the
+// v3 identity service or other providers understand must be left blank. A TenantID, a TenantName,
+// or both may be used to scope the token, unless it's scoped by a TrustID. ExtraHeaders may not
+// include any of the headers that gophercloud sets itself.
+//
+// Two conditions that might look like mistakes are deliberately accepted. Providing both a TenantID
+// and a TenantName is valid v2 authentication: the identity service scopes the token by the
+// TenantID, and some providers require both. And the IdentityEndpoint isn't checked, because Create
+// sends the request to the ServiceClient it's given rather than to the IdentityEndpoint;
+// openstack.AuthenticatedClient reports a missing one as ErrNoIdentityEndpoint.
+func (auth AuthOptions) Validate() error {
 	// Error out if an unsupported auth option is present.
 	if auth.UserID != "" {
-		return nil, ErrUserIDProvided
+		return ErrUserIDProvided
 	}
 	if auth.APIKey != "" {
-		return nil, ErrAPIKeyProvided
+		return ErrAPIKeyProvided
 	}
 	if auth.DomainID != "" {
-		return nil, ErrDomainIDProvided
+		return ErrDomainIDProvided
 	}
 	if auth.DomainName != "" {
-		return nil, ErrDomainNameProvided
+		return ErrDomainNameProvided
 	}
 
-	// Populate the request map.
-	authMap := make(map[string]interface{})
+	// Require a complete set of credentials. A TokenID takes precedence over a Username and Password.
+	if auth.TokenID == "" {
+		if auth.Username == "" {
+			return ErrCredentialsRequired
+		}
+		if auth.Password == "" {
+			return ErrPasswordRequired
+		}
+	}
+
+	// A trust carries its own scope.
+	if auth.TrustID != "" && (auth.TenantID != "" || auth.TenantName != "") {
+		return ErrTrustIDWithTenant
+	}
+
+	return auth.validateExtraHeaders()
+}
 
-	if auth.Username != "" {
-		if auth.Password != "" {
-			authMap["passwordCredentials"] = map[string]interface{}{
-				"username": auth.Username,
-				"password": auth.Password,
+// validateExtraHeaders ensures that the ExtraHeaders don't clobber the headers that describe the
+// request. Header names are compared in canonical form, since an http.Header built as a literal
+// may use any capitalization.
+func (auth AuthOptions) validateExtraHeaders() error {
+	for name := range auth.ExtraHeaders {
+		name = http.CanonicalHeaderKey(name)
+		for _, header := range reservedHeaders {
+			if name == header {
+				return reservedHeaderErr(header)
 			}
-		} else {
-			return nil, ErrPasswordRequired
 		}
-	} else if auth.TokenID != "" {
+	}
+	return nil
+}
+
+// ToTokenCreateMap converts AuthOptions into nested maps that can be serialized into a JSON
+// request. The options are checked with Validate first, so that Create fails without a round-trip
+// to the identity service if they're incomplete.
+func (auth AuthOptions) ToTokenCreateMap() (map[string]interface{}, error) {
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Populate the request map.
+	authMap := make(map[string]interface{})
+
+	if auth.TokenID != "" {
 		authMap["token"] = map[string]interface{}{
 			"id": auth.TokenID,
 		}
 	} else {
-		return nil, fmt.Errorf("You must provide either username/password or tenantID/token values.")
+		authMap["passwordCredentials"] = map[string]interface{}{
+			"username": auth.Username,
+			"password": auth.Password,
+		}
 	}
 
 	if auth.TenantID != "" {
@@ -68,35 +126,163 @@ func (auth AuthOptions) ToTokenCreateMap() (map[string]interface{}, error) {
 	if auth.TenantName != "" {
 		authMap["tenantName"] = auth.TenantName
 	}
+	if auth.TrustID != "" {
+		authMap["trust_id"] = auth.TrustID
+	}
 
 	return map[string]interface{}{"auth": authMap}, nil
 }
 
+// ToTokenCreateHeaders converts the ExtraHeaders into the form expected by RequestOpts. Headers with
+// several values are joined with commas, and empty values are dropped.
+func (auth AuthOptions) ToTokenCreateHeaders() (map[string]string, error) {
+	if err := auth.validateExtraHeaders(); err != nil {
+		return nil, err
+	}
+	if len(auth.ExtraHeaders) == 0 {
+		return nil, nil
+	}
+
+	headers := make(map[string]string, len(auth.ExtraHeaders))
+	for name, values := range auth.ExtraHeaders {
+		if value := strings.Join(values, ", "); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers, nil
+}
+
 // Create authenticates to the identity service and attempts to acquire a Token.
 // If successful, the CreateResult
 // Generally, rather than interact with this call directly, end users should call openstack.AuthenticatedClient(),
 // which abstracts all of the gory details about navigating service catalogs and such.
 func Create(client *gophercloud.ServiceClient, auth AuthOptionsBuilder) CreateResult {
+	return CreateContext(context.Background(), client, auth)
+}
+
+// CreateContext is like Create, but the request is abandoned if ctx is cancelled or its deadline
+// passes before the identity service responds.
+func CreateContext(ctx context.Context, client *gophercloud.ServiceClient, auth AuthOptionsBuilder) CreateResult {
 	request, err := auth.ToTokenCreateMap()
 	if err != nil {
 		return CreateResult{gophercloud.Result{Err: err}}
 	}
 
+	var headers map[string]string
+	if builder, ok := auth.(AuthHeadersBuilder); ok {
+		headers, err = builder.ToTokenCreateHeaders()
+		if err != nil {
+			return CreateResult{gophercloud.Result{Err: err}}
+		}
+	}
+
 	var result CreateResult
-	_, result.Err = client.Post(CreateURL(client), request, &result.Body, &gophercloud.RequestOpts{
-		OkCodes: []int{200, 203},
+	var response *http.Response
+	response, result.Err = client.Post(CreateURL(client), request, &result.Body, &gophercloud.RequestOpts{
+		OkCodes:     []int{200, 203},
+		MoreHeaders: headers,
+		Context:     ctx,
+		OmitToken:   true,
 	})
+	if response != nil {
+		result.Header = response.Header
+	}
 	return result
 }
 
-// Validates and retrieves information for user's token.
+// Rescope exchanges an existing token, usually an unscoped one, for a new token scoped to the
+// tenant with the given ID. It authenticates with the token itself, so the user's credentials don't
+// need to be retained. Interpret the result as you would the result of Create.
+func Rescope(client *gophercloud.ServiceClient, tokenID, tenantID string) CreateResult {
+	return Create(client, WrapOptions(gophercloud.AuthOptions{
+		TokenID:  tokenID,
+		TenantID: tenantID,
+	}))
+}
+
+// Get validates a token and retrieves information about the tenant and user associated with it.
+// If the identity service doesn't recognize the token, the GetResult will report ErrTokenNotFound.
+// An empty token ID is reported as ErrTokenIDRequired without contacting the identity service.
 func Get(client *gophercloud.ServiceClient, token string) GetResult {
+	return GetContext(context.Background(), client, token)
+}
+
+// GetContext is like Get, but the request is abandoned if ctx is cancelled or its deadline passes
+// before the identity service responds.
+func GetContext(ctx context.Context, client *gophercloud.ServiceClient, token string) GetResult {
 	var result GetResult
-	_, result.Err = client.Get(GetURL(client, token), &result.Body, &gophercloud.RequestOpts{
+	if token == "" {
+		result.Err = ErrTokenIDRequired
+		return result
+	}
+
+	var response *http.Response
+	response, result.Err = client.Get(GetURL(client, token), &result.Body, &gophercloud.RequestOpts{
 		OkCodes: []int{200, 203},
+		Context: ctx,
 	})
-	if result.Err != nil {
-		return result
+	if response != nil {
+		result.Header = response.Header
+	}
+	if err, ok := result.Err.(*gophercloud.UnexpectedResponseCodeError); ok && err.Actual == 404 {
+		result.Err = ErrTokenNotFound
+	}
+	return result
+}
+
+// ValidateBatchConcurrency limits how many tokens ValidateBatch validates at once, so that a burst of
+// tokens doesn't overwhelm the identity service. Values below one are treated as one.
+var ValidateBatchConcurrency = 8
+
+// ValidateBatch validates several tokens concurrently, as Get does for one, with at most
+// ValidateBatchConcurrency requests in flight. It returns the result for each distinct token,
+// keyed by its ID; a token that's listed more than once is validated once. An empty token ID is
+// reported as ErrTokenIDRequired, keyed by "", without contacting the identity service.
+func ValidateBatch(client *gophercloud.ServiceClient, tokenIDs []string) map[string]GetResult {
+	results := make(map[string]GetResult, len(tokenIDs))
+	pending := make(chan string)
+	var mut sync.Mutex
+	var wg sync.WaitGroup
+
+	workers := ValidateBatchConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for token := range pending {
+				result := Get(client, token)
+				mut.Lock()
+				results[token] = result
+				mut.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool, len(tokenIDs))
+	for _, token := range tokenIDs {
+		if !seen[token] {
+			seen[token] = true
+			pending <- token
+		}
+	}
+	close(pending)
+	wg.Wait()
+
+	return results
+}
+
+// Revoke invalidates a token immediately, so that it can no longer be used. If the identity service
+// doesn't recognize the token, the RevokeResult will report ErrTokenNotFound.
+func Revoke(client *gophercloud.ServiceClient, token string) RevokeResult {
+	var result RevokeResult
+	_, result.Err = client.Delete(RevokeURL(client, token), &gophercloud.RequestOpts{
+		OkCodes: []int{204},
+	})
+	if err, ok := result.Err.(*gophercloud.UnexpectedResponseCodeError); ok && err.Actual == 404 {
+		result.Err = ErrTokenNotFound
 	}
 	return result
 }
@@ -1,8 +1,12 @@
 package tokens
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rackspace/gophercloud"
 	th "github.com/rackspace/gophercloud/testhelper"
@@ -44,6 +48,12 @@ func TestCreateWithPassword(t *testing.T) {
   `))
 }
 
+func TestCreateRequestID(t *testing.T) {
+	result := tokenPost(t, gophercloud.AuthOptions{Username: "me", Password: "swordfish"}, "")
+	th.AssertNoErr(t, result.Err)
+	th.CheckEquals(t, ExpectedRequestID, result.RequestID())
+}
+
 func TestCreateTokenWithTenantID(t *testing.T) {
 	options := gophercloud.AuthOptions{
 		Username: "me",
@@ -84,6 +94,128 @@ func TestCreateTokenWithTenantName(t *testing.T) {
   `))
 }
 
+func TestRescope(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenPost(t, `
+    {
+      "auth": {
+        "token": {
+          "id": "unscopedtoken"
+        },
+        "tenantId": "fc394f2ab2df4114bde39905f800dc57"
+      }
+    }
+  `)
+
+	IsSuccessful(t, Rescope(client.ServiceClient(), "unscopedtoken", "fc394f2ab2df4114bde39905f800dc57"))
+}
+
+func TestCreateWithTokenID(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		TokenID:  "cbc36478b0bd8e67e89469c7749d4127",
+		TenantID: "fc394f2ab2df4114bde39905f800dc57",
+	}
+
+	IsSuccessful(t, tokenPost(t, options, `
+    {
+      "auth": {
+        "token": {
+          "id": "cbc36478b0bd8e67e89469c7749d4127"
+        },
+        "tenantId": "fc394f2ab2df4114bde39905f800dc57"
+      }
+    }
+  `))
+}
+
+func TestCreateWithTokenIDAndPassword(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		Username: "me",
+		Password: "swordfish",
+		TokenID:  "cbc36478b0bd8e67e89469c7749d4127",
+	}
+
+	IsSuccessful(t, tokenPost(t, options, `
+    {
+      "auth": {
+        "token": {
+          "id": "cbc36478b0bd8e67e89469c7749d4127"
+        }
+      }
+    }
+  `))
+}
+
+func TestCreateTokenWithTrustID(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		TokenID: "trusteetoken",
+		TrustID: "0d5a0e3f9a8f4c8da2e7c1a1f5b3c4d6",
+	}
+
+	IsSuccessful(t, tokenPost(t, options, `
+    {
+      "auth": {
+        "token": {
+          "id": "trusteetoken"
+        },
+        "trust_id": "0d5a0e3f9a8f4c8da2e7c1a1f5b3c4d6"
+      }
+    }
+  `))
+}
+
+func TestProhibitTrustIDWithTenant(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		TokenID:  "trusteetoken",
+		TrustID:  "0d5a0e3f9a8f4c8da2e7c1a1f5b3c4d6",
+		TenantID: "fc394f2ab2df4114bde39905f800dc57",
+	}
+
+	tokenPostErr(t, options, ErrTrustIDWithTenant)
+}
+
+func TestCreateWithExtraHeaders(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/tokens", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "POST")
+		th.TestHeader(t, r, "Content-Type", "application/json")
+		th.TestHeader(t, r, "X-Tenant-Route", "east")
+		th.TestHeader(t, r, "X-Trace", "a, b")
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, TokenCreationResponse)
+	})
+
+	options := gophercloud.AuthOptions{
+		Username: "me",
+		Password: "swordfish",
+		ExtraHeaders: http.Header{
+			"X-Tenant-Route": []string{"east"},
+			"X-Trace":        []string{"a", "b"},
+		},
+	}
+	IsSuccessful(t, Create(client.ServiceClient(), WrapOptions(options)))
+}
+
+func TestProhibitReservedExtraHeaders(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		Username:     "me",
+		Password:     "swordfish",
+		ExtraHeaders: http.Header{"Content-Type": []string{"text/plain"}},
+	}
+
+	err := Create(client.ServiceClient(), WrapOptions(options)).Err
+	th.CheckEquals(t, "The Content-Type header is set by gophercloud, so it can't be provided in ExtraHeaders", err.Error())
+
+	// Header names that aren't in canonical form are caught too.
+	options.ExtraHeaders = http.Header{"x-auth-token": []string{"aaaabbbbccccdddd"}}
+	err = Create(client.ServiceClient(), WrapOptions(options)).Err
+	th.CheckEquals(t, "The X-Auth-Token header is set by gophercloud, so it can't be provided in ExtraHeaders", err.Error())
+}
+
 func TestProhibitUserID(t *testing.T) {
 	options := gophercloud.AuthOptions{
 		Username: "me",
@@ -132,6 +264,22 @@ func TestRequireUsername(t *testing.T) {
 	tokenPostErr(t, options, fmt.Errorf("You must provide either username/password or tenantID/token values."))
 }
 
+func TestValidate(t *testing.T) {
+	valid := []gophercloud.AuthOptions{
+		gophercloud.AuthOptions{Username: "me", Password: "swordfish"},
+		gophercloud.AuthOptions{TokenID: "aaaabbbbccccdddd"},
+		gophercloud.AuthOptions{Username: "me", Password: "swordfish", TenantID: "fc394f2ab2df4114bde39905f800dc57", TenantName: "demo"},
+		gophercloud.AuthOptions{Username: "me", TokenID: "aaaabbbbccccdddd"},
+	}
+	for _, options := range valid {
+		th.CheckNoErr(t, WrapOptions(options).Validate())
+	}
+
+	th.CheckEquals(t, ErrCredentialsRequired, WrapOptions(gophercloud.AuthOptions{TenantID: "fc394f2ab2df4114bde39905f800dc57"}).Validate())
+	th.CheckEquals(t, ErrPasswordRequired, WrapOptions(gophercloud.AuthOptions{Username: "me"}).Validate())
+	th.CheckEquals(t, ErrUserIDProvided, WrapOptions(gophercloud.AuthOptions{UserID: "me", Password: "swordfish"}).Validate())
+}
+
 func TestRequirePassword(t *testing.T) {
 	options := gophercloud.AuthOptions{
 		Username: "me",
@@ -139,3 +287,110 @@ func TestRequirePassword(t *testing.T) {
 
 	tokenPostErr(t, options, ErrPasswordRequired)
 }
+
+func TestGetToken(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenGet(t, "aaaabbbbccccdddd")
+
+	result := Get(client.ServiceClient(), "aaaabbbbccccdddd")
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, ExpectedToken.ID, token.ID)
+	th.CheckEquals(t, ExpectedToken.ExpiresAt, token.ExpiresAt)
+	th.CheckEquals(t, ExpectedToken.IssuedAt, token.IssuedAt)
+	th.CheckDeepEquals(t, ExpectedToken.AuditIDs, token.AuditIDs)
+	th.CheckDeepEquals(t, ExpectedToken.Tenant, token.Tenant)
+	th.CheckEquals(t, ExpectedUser.ID, token.UserID)
+
+	user, err := result.ExtractUser()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, ExpectedUser, user)
+}
+
+func TestCreateContextCancelled(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenPost(t, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	options := gophercloud.AuthOptions{Username: "me", Password: "swordfish"}
+	err := CreateContext(ctx, client.ServiceClient(), AuthOptions{options}).Err
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("Expected the request to be cancelled, but got %v", err)
+	}
+}
+
+func TestGetContextDeadline(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/tokens/aaaabbbbccccdddd", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := GetContext(ctx, client.ServiceClient(), "aaaabbbbccccdddd").Err
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("Expected the request to time out, but got %v", err)
+	}
+}
+
+func TestGetTokenNotFound(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenGetNotFound(t, "aaaabbbbccccdddd")
+
+	_, err := Get(client.ServiceClient(), "aaaabbbbccccdddd").ExtractToken()
+	th.CheckEquals(t, ErrTokenNotFound, err)
+}
+
+func TestValidateBatch(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenGet(t, "aaaabbbbccccdddd")
+	HandleTokenGetNotFound(t, "eeeeffffgggghhhh")
+
+	defer func(concurrency int) { ValidateBatchConcurrency = concurrency }(ValidateBatchConcurrency)
+	ValidateBatchConcurrency = 2
+
+	results := ValidateBatch(client.ServiceClient(), []string{"aaaabbbbccccdddd", "eeeeffffgggghhhh", "aaaabbbbccccdddd", ""})
+	th.CheckEquals(t, 3, len(results))
+
+	token, err := results["aaaabbbbccccdddd"].ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, ExpectedToken.ID, token.ID)
+
+	_, err = results["eeeeffffgggghhhh"].ExtractToken()
+	th.CheckEquals(t, ErrTokenNotFound, err)
+
+	// The empty ID is rejected without requesting the tokens collection.
+	_, err = results[""].ExtractToken()
+	th.CheckEquals(t, ErrTokenIDRequired, err)
+}
+
+func TestRevokeToken(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	HandleTokenDelete(t, "aaaabbbbccccdddd")
+
+	err := Revoke(client.ServiceClient(), "aaaabbbbccccdddd").ExtractErr()
+	th.AssertNoErr(t, err)
+}
+
+func TestRevokeTokenNotFound(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+	th.Mux.HandleFunc("/tokens/aaaabbbbccccdddd", func(w http.ResponseWriter, r *http.Request) {
+		th.TestMethod(t, r, "DELETE")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := Revoke(client.ServiceClient(), "aaaabbbbccccdddd").ExtractErr()
+	th.CheckEquals(t, ErrTokenNotFound, err)
+}
@@ -1,6 +1,10 @@
 package tokens
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -21,14 +25,108 @@ type Token struct {
 	// See the AuthOptions structure for more details.
 	ExpiresAt time.Time
 
+	// IssuedAt is the time at which the identity service issued the token. Some providers don't report
+	// it, in which case it's left as the zero value.
+	IssuedAt time.Time
+
+	// AuthenticatedAt is the local time, according to Clock, at which CreateResult.ExtractToken
+	// extracted the token. Unlike IssuedAt, it doesn't depend on the identity service's clock. It's
+	// left as the zero value for tokens extracted from a GetResult.
+	AuthenticatedAt time.Time
+
+	// AuditIDs trace the token's lineage: the first is the token's own audit ID, and the second, if
+	// present, is that of the token it was rescoped from. It's empty if the provider doesn't report
+	// them.
+	AuditIDs []string
+
+	// Extra holds any attributes of the token that aren't mapped to the fields above, such as
+	// "OS-TRUST:trust" or "bind", keyed by their names in the response. It's nil if there are none.
+	Extra map[string]interface{}
+
 	// Tenant provides information about the tenant to which this token grants access.
+	// It's left as the zero value for an unscoped token; use IsScoped to tell the two apart.
 	Tenant tenants.Tenant
 
+	// Domain is the domain to which the token is scoped, for identity services that report one.
+	// The v2 identity API has no notion of domains, so it's normally left as the zero value; it exists
+	// so that code handling both v2 and v3 tokens can share a model. Use IsDomainScoped to check it.
+	Domain Domain
+
+	// Scope is TokenScopeProject if the token is scoped to a tenant, TokenScopeDomain if it's scoped
+	// to a domain, and TokenScopeUnscoped otherwise.
+	Scope gophercloud.TokenScope
+
 	// the owner user of token
 	UserName string
 	UserID   string
 }
 
+// Domain identifies a domain to which a token may be scoped.
+type Domain struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+}
+
+// Clock reports the current time to IsExpired and WillExpireWithin, and therefore to TokenCache, and
+// stamps Token.AuthenticatedAt. It defaults to time.Now; tests may replace it to exercise expiry
+// deterministically.
+var Clock = time.Now
+
+// ExpirySkew is how long before its stated expiry a token is already treated as expired by IsExpired
+// and WillExpireWithin, and therefore by TokenCache. It tolerates differences between the local
+// clock and the identity service's. Set it to zero to trust ExpiresAt exactly.
+var ExpirySkew = gophercloud.DefaultExpirySkew
+
+// IsScoped reports whether the token grants access to a tenant. Tokens acquired without specifying
+// a TenantID or TenantName are unscoped, and most services will reject them.
+func (t Token) IsScoped() bool {
+	return t.Tenant.ID != ""
+}
+
+// IsDomainScoped reports whether the token is scoped to a domain rather than to a tenant.
+func (t Token) IsDomainScoped() bool {
+	return t.Domain.ID != ""
+}
+
+// tokenScope determines the Scope of a token from the tenant and domain it was issued for. A tenant
+// takes precedence over a domain, as it does for Identity v3 tokens.
+func tokenScope(tenant tenants.Tenant, domain Domain) gophercloud.TokenScope {
+	switch {
+	case tenant.ID != "":
+		return gophercloud.TokenScopeProject
+	case domain.ID != "":
+		return gophercloud.TokenScopeDomain
+	default:
+		return gophercloud.TokenScopeUnscoped
+	}
+}
+
+// SameScope reports whether other grants access to the same tenant and domain as t, so that a
+// refreshed token can be checked for a change of scope. It intentionally ignores ID, since each new
+// token has a different one, as well as the expiry and the other attributes of the token.
+func (t Token) SameScope(other Token) bool {
+	return t.Tenant.ID == other.Tenant.ID &&
+		t.Tenant.Name == other.Tenant.Name &&
+		t.Domain == other.Domain
+}
+
+// IsExpired reports whether the token's expiration time, brought forward by ExpirySkew, has already
+// passed. A token with a zero-value ExpiresAt is considered expired, since it was likely never
+// parsed successfully.
+func (t Token) IsExpired() bool {
+	return t.WillExpireWithin(0)
+}
+
+// WillExpireWithin reports whether the token will expire within the given duration from now,
+// allowing for ExpirySkew. Use it to re-authenticate proactively before starting a long-running
+// operation.
+func (t Token) WillExpireWithin(d time.Duration) bool {
+	if t.ExpiresAt.IsZero() {
+		return true
+	}
+	return !Clock().Add(d + ExpirySkew).Before(t.ExpiresAt)
+}
+
 // Endpoint represents a single API endpoint offered by a service.
 // It provides the public and internal URLs, if supported, along with a region specifier, again if provided.
 // The significance of the Region field will depend upon your provider.
@@ -38,14 +136,108 @@ type Token struct {
 //
 // In all cases, fields which aren't supported by the provider and service combined will assume a zero-value ("").
 type Endpoint struct {
-	TenantID    string `mapstructure:"tenantId"`
-	PublicURL   string `mapstructure:"publicURL"`
-	InternalURL string `mapstructure:"internalURL"`
-	AdminURL    string `mapstructure:"adminURL"`
-	Region      string `mapstructure:"region"`
-	VersionID   string `mapstructure:"versionId"`
-	VersionInfo string `mapstructure:"versionInfo"`
-	VersionList string `mapstructure:"versionList"`
+	TenantID    string `mapstructure:"tenantId" json:"tenantId,omitempty"`
+	PublicURL   string `mapstructure:"publicURL" json:"publicURL,omitempty"`
+	InternalURL string `mapstructure:"internalURL" json:"internalURL,omitempty"`
+	AdminURL    string `mapstructure:"adminURL" json:"adminURL,omitempty"`
+	Region      string `mapstructure:"region" json:"region,omitempty"`
+	VersionID   string `mapstructure:"versionId" json:"versionId,omitempty"`
+	VersionInfo string `mapstructure:"versionInfo" json:"versionInfo,omitempty"`
+	VersionList string `mapstructure:"versionList" json:"versionList,omitempty"`
+
+	// Extra holds any string-valued attributes of the endpoint that aren't mapped to the fields above,
+	// such as provider-specific URLs, keyed by their names in the catalog. It's nil if there are none.
+	Extra map[string]string `mapstructure:"-" json:"-"`
+}
+
+// knownEndpointFields lists the attributes of an endpoint that are mapped to fields of Endpoint,
+// in lower case, since they're matched case-insensitively.
+var knownEndpointFields = map[string]bool{
+	"tenantid":    true,
+	"publicurl":   true,
+	"internalurl": true,
+	"adminurl":    true,
+	"region":      true,
+	"versionid":   true,
+	"versioninfo": true,
+	"versionlist": true,
+}
+
+// extraEndpointFields collects the string-valued attributes of a raw endpoint that aren't known.
+func extraEndpointFields(raw map[string]interface{}) map[string]string {
+	var extra map[string]string
+	for key, value := range raw {
+		s, ok := value.(string)
+		if !ok || knownEndpointFields[strings.ToLower(key)] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]string)
+		}
+		extra[key] = s
+	}
+	return extra
+}
+
+// endpointJSON has the same fields as Endpoint, but none of its methods, so that Endpoint's own
+// JSON methods can delegate to the standard encoding.
+type endpointJSON Endpoint
+
+// MarshalJSON serializes the endpoint's typed fields as usual, alongside the attributes in Extra.
+func (e Endpoint) MarshalJSON() ([]byte, error) {
+	typed, err := json.Marshal(endpointJSON(e))
+	if err != nil || len(e.Extra) == 0 {
+		return typed, err
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(typed, &merged); err != nil {
+		return nil, err
+	}
+	for key, value := range e.Extra {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON parses an endpoint, collecting unknown string-valued attributes in Extra.
+func (e *Endpoint) UnmarshalJSON(data []byte) error {
+	var typed endpointJSON
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Endpoint(typed)
+	e.Extra = extraEndpointFields(raw)
+	return nil
+}
+
+// URL returns the endpoint's public, internal or admin URL, as selected by availability, normalized
+// to end with a /. It returns ErrEndpointURLMissing if the endpoint doesn't offer a URL with the
+// requested availability.
+func (e Endpoint) URL(availability gophercloud.Availability) (string, error) {
+	var url string
+	switch availability {
+	case gophercloud.AvailabilityPublic:
+		url = e.PublicURL
+	case gophercloud.AvailabilityInternal:
+		url = e.InternalURL
+	case gophercloud.AvailabilityAdmin:
+		url = e.AdminURL
+	default:
+		return "", fmt.Errorf("Unexpected availability in endpoint query: %s", availability)
+	}
+
+	if url == "" {
+		return "", ErrEndpointURLMissing
+	}
+	return gophercloud.NormalizeURL(url), nil
 }
 
 // CatalogEntry provides a type-safe interface to an Identity API V2 service catalog listing.
@@ -55,16 +247,20 @@ type Endpoint struct {
 // Note: when looking for the desired service, try, whenever possible, to key off the type field.
 // Otherwise, you'll tie the representation of the service to a specific provider.
 type CatalogEntry struct {
+	// ID is the identity service's unique identifier for the service. Older identity services don't
+	// report it, in which case it's left blank.
+	ID string `mapstructure:"id" json:"id,omitempty"`
+
 	// Name will contain the provider-specified name for the service.
-	Name string `mapstructure:"name"`
+	Name string `mapstructure:"name" json:"name"`
 
 	// Type will contain a type string if OpenStack defines a type for the service.
 	// Otherwise, for provider-specific services, the provider may assign their own type strings.
-	Type string `mapstructure:"type"`
+	Type string `mapstructure:"type" json:"type"`
 
 	// Endpoints will let the caller iterate over all the different endpoints that may exist for
 	// the service.
-	Endpoints []Endpoint `mapstructure:"endpoints"`
+	Endpoints []Endpoint `mapstructure:"endpoints" json:"endpoints"`
 }
 
 // ServiceCatalog provides a view into the service catalog from a previous, successful authentication.
@@ -72,6 +268,230 @@ type ServiceCatalog struct {
 	Entries []CatalogEntry
 }
 
+// MarshalJSON serializes a ServiceCatalog in the same shape as the "serviceCatalog" section of an
+// Identity v2 authentication response: a list of entries with "id", "name", "type" and "endpoints" keys,
+// whose endpoints use the "publicURL", "internalURL", "adminURL", "region", "tenantId", "versionId",
+// "versionInfo" and "versionList" keys. Use UnmarshalServiceCatalog to read it back.
+func (c *ServiceCatalog) MarshalJSON() ([]byte, error) {
+	entries := c.Entries
+	if entries == nil {
+		entries = []CatalogEntry{}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalServiceCatalog parses a ServiceCatalog that was serialized by its MarshalJSON method.
+func UnmarshalServiceCatalog(data []byte) (*ServiceCatalog, error) {
+	var entries []CatalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return &ServiceCatalog{Entries: entries}, nil
+}
+
+// FilterByRegion returns a new ServiceCatalog that only contains the endpoints located in the given
+// region. Entries left without any endpoints are dropped. If region is empty, the catalog is returned
+// unchanged.
+func (c *ServiceCatalog) FilterByRegion(region string) *ServiceCatalog {
+	if region == "" {
+		return c
+	}
+
+	filtered := &ServiceCatalog{Entries: make([]CatalogEntry, 0, len(c.Entries))}
+	for _, entry := range c.Entries {
+		endpoints := make([]Endpoint, 0, len(entry.Endpoints))
+		for _, endpoint := range entry.Endpoints {
+			if endpoint.Region == region {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+		if len(endpoints) > 0 {
+			entry.Endpoints = endpoints
+			filtered.Entries = append(filtered.Entries, entry)
+		}
+	}
+	return filtered
+}
+
+// Sort orders the catalog deterministically, in place: entries by Type, then Name, then ID, and each
+// entry's endpoints by Region, then by their public, internal and admin URLs. Nothing is dropped or
+// merged, and entries or endpoints that compare equal keep their relative order.
+func (c *ServiceCatalog) Sort() {
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		a, b := c.Entries[i], c.Entries[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+
+	for _, entry := range c.Entries {
+		endpoints := entry.Endpoints
+		sort.SliceStable(endpoints, func(i, j int) bool {
+			a, b := endpoints[i], endpoints[j]
+			if a.Region != b.Region {
+				return a.Region < b.Region
+			}
+			if a.PublicURL != b.PublicURL {
+				return a.PublicURL < b.PublicURL
+			}
+			if a.InternalURL != b.InternalURL {
+				return a.InternalURL < b.InternalURL
+			}
+			return a.AdminURL < b.AdminURL
+		})
+	}
+}
+
+// ServiceTypes returns the distinct service types offered by the catalog, in the order in which they
+// first appear.
+func (c *ServiceCatalog) ServiceTypes() []string {
+	seen := make(map[string]bool)
+	types := make([]string, 0, len(c.Entries))
+	for _, entry := range c.Entries {
+		if !seen[entry.Type] {
+			seen[entry.Type] = true
+			types = append(types, entry.Type)
+		}
+	}
+	return types
+}
+
+// Regions returns the distinct regions in which a service of the given type has endpoints, in the
+// order in which they first appear. Providers that don't use regions will report a single empty
+// region.
+func (c *ServiceCatalog) Regions(serviceType string) []string {
+	seen := make(map[string]bool)
+	regions := make([]string, 0)
+	for _, entry := range c.Entries {
+		if entry.Type != serviceType {
+			continue
+		}
+		for _, endpoint := range entry.Endpoints {
+			if !seen[endpoint.Region] {
+				seen[endpoint.Region] = true
+				regions = append(regions, endpoint.Region)
+			}
+		}
+	}
+	return regions
+}
+
+// ServicesWithAvailability returns the distinct types of the services that offer a URL with the
+// given availability in the given region, in the order in which they first appear. An empty region
+// matches endpoints in any region.
+func (c *ServiceCatalog) ServicesWithAvailability(availability gophercloud.Availability, region string) []string {
+	seen := make(map[string]bool)
+	types := make([]string, 0)
+	for _, entry := range c.Entries {
+		if seen[entry.Type] {
+			continue
+		}
+		for _, endpoint := range entry.Endpoints {
+			if region != "" && endpoint.Region != region {
+				continue
+			}
+			if _, err := endpoint.URL(availability); err == nil {
+				seen[entry.Type] = true
+				types = append(types, entry.Type)
+				break
+			}
+		}
+	}
+	return types
+}
+
+// AllURLs returns every URL the catalog offers with the given availability, across all services and
+// regions, normalized and without duplicates, in the order in which they first appear. Endpoints
+// that don't offer the availability are skipped.
+func (c *ServiceCatalog) AllURLs(availability gophercloud.Availability) []string {
+	seen := make(map[string]bool)
+	urls := make([]string, 0)
+	for _, entry := range c.Entries {
+		for _, endpoint := range entry.Endpoints {
+			url, err := endpoint.URL(availability)
+			if err != nil || seen[url] {
+				continue
+			}
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// Validate inspects the catalog for anomalies that make endpoint lookups fail or behave
+// unexpectedly: catalog entries without any endpoints, and several endpoints offering a URL with the
+// same availability for the same service type and region, which V2EndpointURL can only tell apart
+// with additional EndpointOpts such as a Name. Every problem found is reported, in catalog order. A
+// well-formed catalog yields an empty slice.
+func (c *ServiceCatalog) Validate() []error {
+	type endpointKey struct {
+		serviceType  string
+		region       string
+		availability gophercloud.Availability
+	}
+
+	availabilities := []gophercloud.Availability{
+		gophercloud.AvailabilityPublic,
+		gophercloud.AvailabilityInternal,
+		gophercloud.AvailabilityAdmin,
+	}
+
+	errs := make([]error, 0)
+	counts := make(map[endpointKey]int)
+	for _, entry := range c.Entries {
+		if len(entry.Endpoints) == 0 {
+			errs = append(errs, fmt.Errorf("Catalog entry %q of type %q has no endpoints", entry.Name, entry.Type))
+			continue
+		}
+
+		for _, endpoint := range entry.Endpoints {
+			for _, availability := range availabilities {
+				if _, err := endpoint.URL(availability); err != nil {
+					continue
+				}
+
+				key := endpointKey{serviceType: entry.Type, region: endpoint.Region, availability: availability}
+				counts[key]++
+				if counts[key] == 2 {
+					errs = append(errs, fmt.Errorf("Service type %q has more than one %s endpoint in region %q", entry.Type, availability, endpoint.Region))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// Role is a role granted to the authenticated User, as reported alongside its Token.
+type Role struct {
+	// ID is the unique identifier of the role. Some identity services only report its Name.
+	ID string `mapstructure:"id"`
+
+	// Name is the human-readable name of the role.
+	Name string `mapstructure:"name"`
+}
+
+// User describes the user who owns a Token, as reported by the identity service during
+// authentication.
+type User struct {
+	// ID is the unique identifier of the user.
+	ID string `mapstructure:"id"`
+
+	// Name is the display name of the user.
+	Name string `mapstructure:"name"`
+
+	// Username is the login name of the user. Some providers leave it empty.
+	Username string `mapstructure:"username"`
+
+	// Roles lists the roles granted to the user within the token's scope. It will be empty if the
+	// provider omits them.
+	Roles []Role `mapstructure:"roles"`
+}
+
 // CreateResult defers the interpretation of a created token.
 // Use ExtractToken() to interpret it as a Token, or ExtractServiceCatalog() to interpret it as a service catalog.
 type CreateResult struct {
@@ -83,6 +503,12 @@ type GetResult struct {
 	gophercloud.Result
 }
 
+// RevokeResult is the deferred response from a Revoke call. Use ExtractErr() to learn whether the
+// token was revoked successfully.
+type RevokeResult struct {
+	gophercloud.ErrResult
+}
+
 // ExtractToken returns the just-created Token from a CreateResult.
 func (result CreateResult) ExtractToken() (*Token, error) {
 	if result.Err != nil {
@@ -92,9 +518,12 @@ func (result CreateResult) ExtractToken() (*Token, error) {
 	var response struct {
 		Access struct {
 			Token struct {
-				Expires string         `mapstructure:"expires"`
-				ID      string         `mapstructure:"id"`
-				Tenant  tenants.Tenant `mapstructure:"tenant"`
+				Expires  string         `mapstructure:"expires"`
+				IssuedAt string         `mapstructure:"issued_at"`
+				ID       string         `mapstructure:"id"`
+				Tenant   tenants.Tenant `mapstructure:"tenant"`
+				Domain   Domain         `mapstructure:"domain"`
+				AuditIDs []string       `mapstructure:"audit_ids"`
 			} `mapstructure:"token"`
 		} `mapstructure:"access"`
 	}
@@ -104,19 +533,33 @@ func (result CreateResult) ExtractToken() (*Token, error) {
 		return nil, err
 	}
 
-	expiresTs, err := time.Parse(gophercloud.RFC3339Milli, response.Access.Token.Expires)
+	expiresTs, err := parseTime("expiry", response.Access.Token.Expires)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedTs, err := parseOptionalTime("issued_at", response.Access.Token.IssuedAt)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Token{
-		ID:        response.Access.Token.ID,
-		ExpiresAt: expiresTs,
-		Tenant:    response.Access.Token.Tenant,
+		ID:              response.Access.Token.ID,
+		ExpiresAt:       expiresTs,
+		IssuedAt:        issuedTs,
+		AuthenticatedAt: Clock(),
+		AuditIDs:        auditIDs(response.Access.Token.AuditIDs),
+		Extra:           extraTokenFields(result.Body),
+		Tenant:          response.Access.Token.Tenant,
+		Domain:          response.Access.Token.Domain,
+		Scope:           tokenScope(response.Access.Token.Tenant, response.Access.Token.Domain),
 	}, nil
 }
 
 // ExtractServiceCatalog returns the ServiceCatalog that was generated along with the user's Token.
+// If the catalog doesn't have the expected shape, the error is an *ErrDecodeServiceCatalog naming
+// each offending attribute. Catalog entries whose endpoints are keyed by region in an object, rather
+// than listed in an array, are accepted too; see normalizeCatalogBody.
 func (result CreateResult) ExtractServiceCatalog() (*ServiceCatalog, error) {
 	if result.Err != nil {
 		return nil, result.Err
@@ -128,14 +571,164 @@ func (result CreateResult) ExtractServiceCatalog() (*ServiceCatalog, error) {
 		} `mapstructure:"access"`
 	}
 
-	err := mapstructure.Decode(result.Body, &response)
+	body := normalizeCatalogBody(result.Body)
+	err := mapstructure.Decode(body, &response)
 	if err != nil {
-		return nil, err
+		return nil, decodeServiceCatalogErr(err)
+	}
+
+	// Recover the attributes that mapstructure doesn't map from the raw catalog.
+	root, _ := body.(map[string]interface{})
+	access, _ := root["access"].(map[string]interface{})
+	rawEntries, _ := access["serviceCatalog"].([]interface{})
+	for i, entry := range response.Access.Entries {
+		if i >= len(rawEntries) {
+			break
+		}
+		rawEntry, _ := rawEntries[i].(map[string]interface{})
+		rawEndpoints, _ := rawEntry["endpoints"].([]interface{})
+		for j := range entry.Endpoints {
+			if j >= len(rawEndpoints) {
+				break
+			}
+			rawEndpoint, _ := rawEndpoints[j].(map[string]interface{})
+			entry.Endpoints[j].Extra = extraEndpointFields(rawEndpoint)
+		}
 	}
 
 	return &ServiceCatalog{Entries: response.Access.Entries}, nil
 }
 
+// normalizeCatalogBody converts the catalog entries in an authentication response whose endpoints
+// are keyed by region, as in {"RegionOne": {"publicURL": ...}}, into the usual array form. Each
+// region may hold a single endpoint or an array of them. The endpoints are ordered by region, and
+// those without a region of their own take it from their key. If no entry needs converting, body is
+// returned as-is; otherwise a converted copy is returned, leaving body unchanged.
+func normalizeCatalogBody(body interface{}) interface{} {
+	root, _ := body.(map[string]interface{})
+	access, _ := root["access"].(map[string]interface{})
+	rawEntries, _ := access["serviceCatalog"].([]interface{})
+
+	var entries []interface{}
+	for i, rawEntry := range rawEntries {
+		entry, _ := rawEntry.(map[string]interface{})
+		converted := normalizeCatalogEntry(entry)
+		if converted == nil {
+			continue
+		}
+		if entries == nil {
+			entries = append([]interface{}(nil), rawEntries...)
+		}
+		entries[i] = converted
+	}
+	if entries == nil {
+		return body
+	}
+
+	convertedAccess := make(map[string]interface{}, len(access))
+	for key, value := range access {
+		convertedAccess[key] = value
+	}
+	convertedAccess["serviceCatalog"] = entries
+
+	convertedRoot := make(map[string]interface{}, len(root))
+	for key, value := range root {
+		convertedRoot[key] = value
+	}
+	convertedRoot["access"] = convertedAccess
+	return convertedRoot
+}
+
+// normalizeCatalogEntry converts a raw catalog entry whose endpoints are keyed by region, as
+// normalizeCatalogBody describes, returning a converted copy. It returns nil if the entry's
+// endpoints aren't keyed by region.
+func normalizeCatalogEntry(entry map[string]interface{}) map[string]interface{} {
+	byRegion, ok := entry["endpoints"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	regions := make([]string, 0, len(byRegion))
+	for region := range byRegion {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	endpoints := make([]interface{}, 0, len(regions))
+	for _, region := range regions {
+		values, ok := byRegion[region].([]interface{})
+		if !ok {
+			values = []interface{}{byRegion[region]}
+		}
+		for _, value := range values {
+			endpoints = append(endpoints, withDefaultRegion(value, region))
+		}
+	}
+
+	converted := make(map[string]interface{}, len(entry))
+	for key, value := range entry {
+		converted[key] = value
+	}
+	converted["endpoints"] = endpoints
+	return converted
+}
+
+// withDefaultRegion returns a copy of a raw endpoint with its region set to region, unless it
+// already names one. Values that aren't objects are returned as-is, for mapstructure to report.
+func withDefaultRegion(value interface{}, region string) interface{} {
+	endpoint, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	if existing, _ := endpoint["region"].(string); existing != "" {
+		return endpoint
+	}
+
+	copied := make(map[string]interface{}, len(endpoint)+1)
+	for key, value := range endpoint {
+		copied[key] = value
+	}
+	copied["region"] = region
+	return copied
+}
+
+// ExtractUser returns the User that was authenticated along with the user's Token.
+func (result CreateResult) ExtractUser() (*User, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return extractUser(result.Body)
+}
+
+// ExtractRoles returns the roles granted to the authenticated user, which are useful for making
+// authorization decisions. If the identity service reports none, the slice is empty.
+func (result CreateResult) ExtractRoles() ([]Role, error) {
+	user, err := result.ExtractUser()
+	if err != nil {
+		return nil, err
+	}
+	if user.Roles == nil {
+		return []Role{}, nil
+	}
+	return user.Roles, nil
+}
+
+// extractUser decodes the "access.user" section shared by token creation and validation responses.
+func extractUser(body interface{}) (*User, error) {
+	var response struct {
+		Access struct {
+			User User `mapstructure:"user"`
+		} `mapstructure:"access"`
+	}
+
+	err := mapstructure.Decode(body, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.Access.User, nil
+}
+
 // createErr quickly packs an error in a CreateResult.
 func createErr(err error) CreateResult {
 	return CreateResult{gophercloud.Result{Err: err}}
@@ -150,8 +743,12 @@ func (result GetResult) ExtractToken() (*Token, error) {
 	var response struct {
 		Access struct {
 			Token struct {
-				Expires string `mapstructure:"expires"`
-				ID      string `mapstructure:"id"`
+				Expires  string         `mapstructure:"expires"`
+				IssuedAt string         `mapstructure:"issued_at"`
+				ID       string         `mapstructure:"id"`
+				Tenant   tenants.Tenant `mapstructure:"tenant"`
+				Domain   Domain         `mapstructure:"domain"`
+				AuditIDs []string       `mapstructure:"audit_ids"`
 			} `mapstructure:"token"`
 			User struct {
 				ID   string `mapstructure:"id"`
@@ -165,7 +762,12 @@ func (result GetResult) ExtractToken() (*Token, error) {
 		return nil, err
 	}
 
-	expiresTs, err := time.Parse(gophercloud.RFC3339Milli, response.Access.Token.Expires)
+	expiresTs, err := parseTime("expiry", response.Access.Token.Expires)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedTs, err := parseOptionalTime("issued_at", response.Access.Token.IssuedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +775,87 @@ func (result GetResult) ExtractToken() (*Token, error) {
 	return &Token{
 		ID:        response.Access.Token.ID,
 		ExpiresAt: expiresTs,
+		IssuedAt:  issuedTs,
+		AuditIDs:  auditIDs(response.Access.Token.AuditIDs),
+		Extra:     extraTokenFields(result.Body),
+		Tenant:    response.Access.Token.Tenant,
+		Domain:    response.Access.Token.Domain,
+		Scope:     tokenScope(response.Access.Token.Tenant, response.Access.Token.Domain),
 		UserID:    response.Access.User.ID,
 		UserName:  response.Access.User.Name,
 	}, nil
 }
+
+// ExtractUser returns the User who owns the validated Token.
+func (result GetResult) ExtractUser() (*User, error) {
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return extractUser(result.Body)
+}
+
+// knownTokenFields lists the attributes of a token that ExtractToken maps to fields of Token.
+var knownTokenFields = map[string]bool{
+	"id":        true,
+	"expires":   true,
+	"issued_at": true,
+	"tenant":    true,
+	"audit_ids": true,
+	"domain":    true,
+}
+
+// extraTokenFields collects the attributes of the "access.token" section that aren't known.
+func extraTokenFields(body interface{}) map[string]interface{} {
+	root, _ := body.(map[string]interface{})
+	access, _ := root["access"].(map[string]interface{})
+	token, _ := access["token"].(map[string]interface{})
+
+	var extra map[string]interface{}
+	for key, value := range token {
+		if knownTokenFields[key] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]interface{})
+		}
+		extra[key] = value
+	}
+	return extra
+}
+
+// auditIDs substitutes an empty slice for missing audit IDs.
+func auditIDs(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+// timeLayouts lists the formats in which identity services are known to report token timestamps,
+// in order of preference.
+var timeLayouts = []string{gophercloud.RFC3339Milli, time.RFC3339, gophercloud.RFC3339NoZ}
+
+// parseTime parses the token timestamp named by field using the first of timeLayouts that fits. If
+// none does, the error reports the raw value so that provider-specific quirks are easy to spot.
+func parseTime(field, raw string) (time.Time, error) {
+	var firstErr error
+	for _, layout := range timeLayouts {
+		ts, err := time.Parse(layout, raw)
+		if err == nil {
+			return ts, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, fmt.Errorf("Unable to parse token %s %q: %s", field, raw, firstErr)
+}
+
+// parseOptionalTime parses a timestamp that the identity service may omit, returning the zero value
+// if it's absent.
+func parseOptionalTime(field, raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return parseTime(field, raw)
+}
@@ -0,0 +1,705 @@
+package tokens
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack/identity/v2/tenants"
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func createResultFromJSON(t *testing.T, body string) CreateResult {
+	var decoded interface{}
+	err := json.Unmarshal([]byte(body), &decoded)
+	th.AssertNoErr(t, err)
+	return CreateResult{gophercloud.Result{Body: decoded}}
+}
+
+func TestExtractUserWithoutRoles(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "user": {
+          "id": "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+          "name": "me"
+        }
+      }
+    }
+  `)
+
+	user, err := result.ExtractUser()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "a4c2b8ed0ff4403f9d7b3bcd5fc11b56", user.ID)
+	th.CheckEquals(t, "me", user.Name)
+	th.CheckEquals(t, "", user.Username)
+	th.CheckEquals(t, 0, len(user.Roles))
+}
+
+func TestExtractUserWithEmptyRoles(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "user": {
+          "id": "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+          "name": "me",
+          "username": "me",
+          "roles": []
+        }
+      }
+    }
+  `)
+
+	user, err := result.ExtractUser()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "me", user.Username)
+	th.CheckEquals(t, 0, len(user.Roles))
+}
+
+func TestExtractUnscopedToken(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd"
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, false, token.IsScoped())
+	th.CheckEquals(t, gophercloud.TokenScopeUnscoped, token.Scope)
+	th.CheckEquals(t, true, ExpectedToken.IsScoped())
+}
+
+func TestExtractTokenExtra(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd",
+          "bind": { "kerberos": "me@EXAMPLE.COM" },
+          "OS-TRUST:trust": { "id": "0d5a0e3f9a8f4c8da2e7c1a1f5b3c4d6", "impersonation": false }
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "aaaabbbbccccdddd", token.ID)
+	th.CheckDeepEquals(t, map[string]interface{}{
+		"bind": map[string]interface{}{"kerberos": "me@EXAMPLE.COM"},
+		"OS-TRUST:trust": map[string]interface{}{
+			"id":            "0d5a0e3f9a8f4c8da2e7c1a1f5b3c4d6",
+			"impersonation": false,
+		},
+	}, token.Extra)
+}
+
+func TestExtractTokenTenantDefaults(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd",
+          "tenant": { "id": "fc394f2ab2df4114bde39905f800dc57", "name": "test", "description": null }
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "fc394f2ab2df4114bde39905f800dc57", token.Tenant.ID)
+	th.CheckEquals(t, "test", token.Tenant.Name)
+	th.CheckEquals(t, "", token.Tenant.Description)
+	th.CheckEquals(t, false, token.Tenant.Enabled)
+}
+
+func TestExtractDomainScopedToken(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd",
+          "domain": { "id": "1789d1", "name": "example.com" }
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, true, token.IsDomainScoped())
+	th.CheckEquals(t, false, token.IsScoped())
+	th.CheckEquals(t, gophercloud.TokenScopeDomain, token.Scope)
+	th.CheckDeepEquals(t, Domain{ID: "1789d1", Name: "example.com"}, token.Domain)
+	th.CheckDeepEquals(t, map[string]interface{}(nil), token.Extra)
+
+	token, err = createResultFromJSON(t, TokenCreationResponse).ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, false, token.IsDomainScoped())
+}
+
+func TestTokenScopePrefersTenant(t *testing.T) {
+	tenant := tenants.Tenant{ID: "fc394f2ab2df4114bde39905f800dc57"}
+	domain := Domain{ID: "1789d1"}
+
+	th.CheckEquals(t, gophercloud.TokenScopeProject, tokenScope(tenant, domain))
+	th.CheckEquals(t, gophercloud.TokenScopeDomain, tokenScope(tenants.Tenant{}, domain))
+	th.CheckEquals(t, gophercloud.TokenScopeUnscoped, tokenScope(tenants.Tenant{}, Domain{}))
+}
+
+func TestExtractTokenWithoutAuditIDs(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd"
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{}, token.AuditIDs)
+}
+
+func TestExtractRoles(t *testing.T) {
+	result := createResultFromJSON(t, TokenCreationResponse)
+	roles, err := result.ExtractRoles()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, ExpectedUser.Roles, roles)
+}
+
+func TestExtractRolesWithoutRoles(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "user": {
+          "id": "a4c2b8ed0ff4403f9d7b3bcd5fc11b56",
+          "name": "me"
+        }
+      }
+    }
+  `)
+
+	roles, err := result.ExtractRoles()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []Role{}, roles)
+}
+
+func TestTokenIsExpired(t *testing.T) {
+	th.CheckEquals(t, true, Token{}.IsExpired())
+	th.CheckEquals(t, true, Token{ExpiresAt: time.Now().Add(-time.Minute)}.IsExpired())
+	th.CheckEquals(t, false, Token{ExpiresAt: time.Now().Add(time.Hour)}.IsExpired())
+}
+
+func TestTokenWillExpireWithin(t *testing.T) {
+	token := Token{ExpiresAt: time.Now().Add(10 * time.Minute)}
+	th.CheckEquals(t, false, token.WillExpireWithin(time.Minute))
+	th.CheckEquals(t, true, token.WillExpireWithin(time.Hour))
+	th.CheckEquals(t, true, Token{}.WillExpireWithin(time.Minute))
+}
+
+func TestExtractTokenAuthenticatedAt(t *testing.T) {
+	now := time.Date(2014, 1, 31, 15, 0, 0, 0, time.UTC)
+	Clock = func() time.Time { return now }
+	defer func() { Clock = time.Now }()
+
+	token, err := createResultFromJSON(t, TokenCreationResponse).ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, now, token.AuthenticatedAt)
+
+	var decoded interface{}
+	th.AssertNoErr(t, json.Unmarshal([]byte(TokenGetResponse), &decoded))
+	token, err = GetResult{gophercloud.Result{Body: decoded}}.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, true, token.AuthenticatedAt.IsZero())
+}
+
+func TestTokenExpirySkew(t *testing.T) {
+	token := Token{ExpiresAt: time.Now().Add(10 * time.Second)}
+	th.CheckEquals(t, true, token.IsExpired())
+
+	ExpirySkew = 5 * time.Second
+	defer func() { ExpirySkew = gophercloud.DefaultExpirySkew }()
+	th.CheckEquals(t, false, token.IsExpired())
+	th.CheckEquals(t, true, token.WillExpireWithin(5*time.Second))
+}
+
+func TestTokenSameScope(t *testing.T) {
+	token := Token{
+		ID:        "aaaa",
+		ExpiresAt: time.Now().Add(time.Hour),
+		Tenant:    tenants.Tenant{ID: "fc394f2ab2df4114bde39905f800dc57", Name: "test"},
+	}
+
+	refreshed := token
+	refreshed.ID = "bbbb"
+	refreshed.ExpiresAt = token.ExpiresAt.Add(time.Hour)
+	refreshed.Tenant.Description = "A tenant's description doesn't affect its scope"
+	th.CheckEquals(t, true, token.SameScope(refreshed))
+
+	rescoped := token
+	rescoped.Tenant = tenants.Tenant{ID: "6f1b4ac1d3e4426e84c9d1f8ec29efa7", Name: "other"}
+	th.CheckEquals(t, false, token.SameScope(rescoped))
+
+	rescoped = token
+	rescoped.Domain = Domain{ID: "1789d1", Name: "example.com"}
+	th.CheckEquals(t, false, token.SameScope(rescoped))
+
+	th.CheckEquals(t, true, Token{ID: "aaaa"}.SameScope(Token{ID: "bbbb"}))
+}
+
+func TestTokenExpiryUsesClock(t *testing.T) {
+	now := time.Date(2014, 1, 31, 15, 30, 58, 0, time.UTC)
+	Clock = func() time.Time { return now }
+	ExpirySkew = 0
+	defer func() { Clock, ExpirySkew = time.Now, gophercloud.DefaultExpirySkew }()
+
+	token := Token{ExpiresAt: now.Add(time.Minute)}
+	th.CheckEquals(t, false, token.IsExpired())
+	th.CheckEquals(t, false, token.WillExpireWithin(time.Minute-time.Nanosecond))
+	th.CheckEquals(t, true, token.WillExpireWithin(time.Minute))
+
+	now = now.Add(time.Minute)
+	th.CheckEquals(t, true, token.IsExpired())
+}
+
+func TestServiceCatalogServiceTypes(t *testing.T) {
+	th.CheckDeepEquals(t, []string{"something", "else"}, ExpectedServiceCatalog.ServiceTypes())
+}
+
+func TestServiceCatalogRegions(t *testing.T) {
+	th.CheckDeepEquals(t, []string{"region0", "region1"}, ExpectedServiceCatalog.Regions("something"))
+	th.CheckDeepEquals(t, []string{"region0"}, ExpectedServiceCatalog.Regions("else"))
+	th.CheckDeepEquals(t, []string{}, ExpectedServiceCatalog.Regions("nope"))
+
+	regionless := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{PublicURL: "http://compute0/"},
+					Endpoint{PublicURL: "http://compute1/"},
+				},
+			},
+		},
+	}
+	th.CheckDeepEquals(t, []string{""}, regionless.Regions("compute"))
+}
+
+func TestServiceCatalogServicesWithAvailability(t *testing.T) {
+	catalog := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "RegionOne", PublicURL: "http://compute1/", InternalURL: "http://compute1.internal/"},
+					Endpoint{Region: "RegionTwo", PublicURL: "http://compute2/"},
+				},
+			},
+			CatalogEntry{
+				Type: "volume",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "RegionTwo", PublicURL: "http://volume2/", InternalURL: "http://volume2.internal/"},
+				},
+			},
+			CatalogEntry{
+				Type: "identity",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "RegionOne", PublicURL: "http://identity/", AdminURL: "http://identity.admin/"},
+				},
+			},
+		},
+	}
+
+	th.CheckDeepEquals(t, []string{"compute"}, catalog.ServicesWithAvailability(gophercloud.AvailabilityInternal, "RegionOne"))
+	th.CheckDeepEquals(t, []string{"volume"}, catalog.ServicesWithAvailability(gophercloud.AvailabilityInternal, "RegionTwo"))
+	th.CheckDeepEquals(t, []string{"compute", "volume"}, catalog.ServicesWithAvailability(gophercloud.AvailabilityInternal, ""))
+	th.CheckDeepEquals(t, []string{"compute", "volume", "identity"}, catalog.ServicesWithAvailability(gophercloud.AvailabilityPublic, ""))
+	th.CheckDeepEquals(t, []string{"identity"}, catalog.ServicesWithAvailability(gophercloud.AvailabilityAdmin, ""))
+	th.CheckDeepEquals(t, []string{}, catalog.ServicesWithAvailability(gophercloud.AvailabilityAdmin, "RegionTwo"))
+}
+
+func TestServiceCatalogAllURLs(t *testing.T) {
+	catalog := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "RegionOne", PublicURL: "http://compute1", InternalURL: "http://internal/"},
+					Endpoint{Region: "RegionTwo", PublicURL: "http://compute2/"},
+				},
+			},
+			CatalogEntry{
+				Type: "volume",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "RegionOne", PublicURL: "http://compute1/", InternalURL: "http://internal"},
+				},
+			},
+		},
+	}
+
+	th.CheckDeepEquals(t, []string{"http://compute1/", "http://compute2/"}, catalog.AllURLs(gophercloud.AvailabilityPublic))
+	th.CheckDeepEquals(t, []string{"http://internal/"}, catalog.AllURLs(gophercloud.AvailabilityInternal))
+	th.CheckDeepEquals(t, []string{}, catalog.AllURLs(gophercloud.AvailabilityAdmin))
+}
+
+func TestExtractTokenWithoutIssuedAt(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "token": {
+          "expires": "2014-01-31T15:30:58Z",
+          "id": "aaaabbbbccccdddd"
+        }
+      }
+    }
+  `)
+
+	token, err := result.ExtractToken()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, true, token.IssuedAt.IsZero())
+	th.CheckEquals(t, time.Date(2014, time.January, 31, 15, 30, 58, 0, time.UTC), token.ExpiresAt)
+}
+
+func TestExtractTokenTimestampLayouts(t *testing.T) {
+	expected := time.Date(2014, time.January, 31, 15, 30, 58, 0, time.UTC)
+	for _, expires := range []string{
+		"2014-01-31T15:30:58.000000Z",
+		"2014-01-31T15:30:58Z",
+		"2014-01-31T15:30:58+00:00",
+		"2014-01-31T17:30:58+02:00",
+		"2014-01-31T15:30:58",
+	} {
+		result := createResultFromJSON(t, `{"access": {"token": {"id": "aaaabbbbccccdddd", "expires": "`+expires+`"}}}`)
+
+		token, err := result.ExtractToken()
+		th.AssertNoErr(t, err)
+		if !token.ExpiresAt.Equal(expected) {
+			t.Errorf("Expected %q to be parsed as %v, but got %v", expires, expected, token.ExpiresAt)
+		}
+	}
+}
+
+func TestExtractTokenBadTimestamp(t *testing.T) {
+	result := createResultFromJSON(t, `{"access": {"token": {"id": "aaaabbbbccccdddd", "expires": "tomorrow"}}}`)
+
+	_, err := result.ExtractToken()
+	if err == nil || !strings.HasPrefix(err.Error(), `Unable to parse token expiry "tomorrow": `) {
+		t.Errorf("Expected an error mentioning the raw timestamp, but got %v", err)
+	}
+}
+
+func TestExtractServiceCatalogEntryID(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "serviceCatalog": [
+          {
+            "id": "1999c3f7a1a44b1e9b38a2b9e6cbd8c1",
+            "name": "nova",
+            "type": "compute",
+            "endpoints": []
+          },
+          {
+            "name": "swift",
+            "type": "object-store",
+            "endpoints": []
+          }
+        ]
+      }
+    }
+  `)
+
+	catalog, err := result.ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "1999c3f7a1a44b1e9b38a2b9e6cbd8c1", catalog.Entries[0].ID)
+	th.CheckEquals(t, "", catalog.Entries[1].ID)
+}
+
+func TestExtractServiceCatalogDecodeError(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "serviceCatalog": [
+          {
+            "name": 42,
+            "type": "compute",
+            "endpoints": [{"region": "RegionOne", "publicURL": ["https://compute.example.com/"]}]
+          },
+          {
+            "name": "swift",
+            "type": "object-store",
+            "endpoints": "none"
+          }
+        ]
+      }
+    }
+  `)
+
+	_, err := result.ExtractServiceCatalog()
+	decodeErr, ok := err.(*ErrDecodeServiceCatalog)
+	if !ok {
+		t.Fatalf("Expected an *ErrDecodeServiceCatalog, but got %#v", err)
+	}
+	th.CheckEquals(t, 3, len(decodeErr.Problems))
+	th.CheckEquals(t, true, strings.HasPrefix(decodeErr.Problems[0], "access.serviceCatalog[0].endpoints[0].publicURL: "))
+	th.CheckEquals(t, true, strings.HasPrefix(decodeErr.Problems[1], "access.serviceCatalog[0].name: "))
+	th.CheckEquals(t, true, strings.HasPrefix(decodeErr.Problems[2], "access.serviceCatalog[1].endpoints: "))
+	th.CheckEquals(t, false, strings.Contains(err.Error(), "\n"))
+	th.CheckEquals(t, true, strings.HasPrefix(err.Error(), "Unable to decode the service catalog: access.serviceCatalog[0].endpoints[0].publicURL: "))
+}
+
+func TestExtractServiceCatalogEndpointsByRegion(t *testing.T) {
+	result := createResultFromJSON(t, `
+    {
+      "access": {
+        "serviceCatalog": [
+          {
+            "name": "nova",
+            "type": "compute",
+            "endpoints": {
+              "RegionTwo": {"publicURL": "https://compute2.example.com/", "versionId": "2"},
+              "RegionOne": [
+                {"publicURL": "https://compute1.example.com/"},
+                {"publicURL": "https://compute1b.example.com/", "region": "RegionOneB"}
+              ]
+            }
+          },
+          {
+            "name": "swift",
+            "type": "object-store",
+            "endpoints": [{"region": "RegionOne", "publicURL": "https://storage.example.com/"}]
+          }
+        ]
+      }
+    }
+  `)
+
+	catalog, err := result.ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []Endpoint{
+		Endpoint{Region: "RegionOne", PublicURL: "https://compute1.example.com/"},
+		Endpoint{Region: "RegionOneB", PublicURL: "https://compute1b.example.com/"},
+		Endpoint{Region: "RegionTwo", PublicURL: "https://compute2.example.com/", VersionID: "2"},
+	}, catalog.Entries[0].Endpoints)
+	th.CheckDeepEquals(t, []Endpoint{
+		Endpoint{Region: "RegionOne", PublicURL: "https://storage.example.com/"},
+	}, catalog.Entries[1].Endpoints)
+
+	// The response itself is left as the provider sent it.
+	access := result.Body.(map[string]interface{})["access"].(map[string]interface{})
+	entry := access["serviceCatalog"].([]interface{})[0].(map[string]interface{})
+	if _, ok := entry["endpoints"].(map[string]interface{}); !ok {
+		t.Errorf("Expected the response's endpoints to be left keyed by region, but got %#v", entry["endpoints"])
+	}
+}
+
+func TestServiceCatalogJSONRoundTrip(t *testing.T) {
+	original := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Name: "cloudFiles",
+				Type: "object-store",
+				Endpoints: []Endpoint{
+					Endpoint{
+						TenantID:    "MossoCloudFS_123",
+						PublicURL:   "https://storage.example.com/v1/MossoCloudFS_123",
+						InternalURL: "https://snet-storage.example.com/v1/MossoCloudFS_123",
+						AdminURL:    "https://admin-storage.example.com/v1/",
+						Region:      "DFW",
+						VersionID:   "1",
+						VersionInfo: "https://storage.example.com/v1/",
+						VersionList: "https://storage.example.com/",
+					},
+				},
+			},
+		},
+	}
+
+	th.AssertJSONEquals(t, `[
+		{
+			"name": "cloudFiles",
+			"type": "object-store",
+			"endpoints": [
+				{
+					"tenantId": "MossoCloudFS_123",
+					"publicURL": "https://storage.example.com/v1/MossoCloudFS_123",
+					"internalURL": "https://snet-storage.example.com/v1/MossoCloudFS_123",
+					"adminURL": "https://admin-storage.example.com/v1/",
+					"region": "DFW",
+					"versionId": "1",
+					"versionInfo": "https://storage.example.com/v1/",
+					"versionList": "https://storage.example.com/"
+				}
+			]
+		}
+	]`, original)
+
+	data, err := json.Marshal(original)
+	th.AssertNoErr(t, err)
+	restored, err := UnmarshalServiceCatalog(data)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, original, restored)
+}
+
+func TestServiceCatalogFilterByRegion(t *testing.T) {
+	expected := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Name: "inscrutablewalrus",
+				Type: "something",
+				Endpoints: []Endpoint{
+					Endpoint{
+						PublicURL: "http://something1:1234/v2/",
+						Region:    "region1",
+					},
+				},
+			},
+		},
+	}
+	th.CheckDeepEquals(t, expected, ExpectedServiceCatalog.FilterByRegion("region1"))
+	th.CheckEquals(t, 2, len(ExpectedServiceCatalog.FilterByRegion("region0").Entries))
+	th.CheckEquals(t, 0, len(ExpectedServiceCatalog.FilterByRegion("nowhere").Entries))
+	th.CheckEquals(t, ExpectedServiceCatalog, ExpectedServiceCatalog.FilterByRegion(""))
+
+	// The original catalog must be left untouched.
+	th.CheckEquals(t, 2, len(ExpectedServiceCatalog.Entries[0].Endpoints))
+}
+
+func TestEndpointURL(t *testing.T) {
+	endpoint := Endpoint{
+		PublicURL:   "https://public.example.com",
+		InternalURL: "https://internal.example.com/",
+	}
+
+	url, err := endpoint.URL(gophercloud.AvailabilityPublic)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://public.example.com/", url)
+
+	url, err = endpoint.URL(gophercloud.AvailabilityInternal)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "https://internal.example.com/", url)
+
+	_, err = endpoint.URL(gophercloud.AvailabilityAdmin)
+	th.CheckEquals(t, ErrEndpointURLMissing, err)
+
+	_, err = endpoint.URL(gophercloud.Availability("wat"))
+	th.CheckEquals(t, "Unexpected availability in endpoint query: wat", err.Error())
+}
+
+func TestServiceCatalogValidate(t *testing.T) {
+	th.CheckEquals(t, 0, len(ExpectedServiceCatalog.Validate()))
+
+	catalog := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				Name: "nova",
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "North", PublicURL: "https://north.compute.com/", AdminURL: "https://admin.compute.com/"},
+				},
+			},
+			CatalogEntry{Name: "swift", Type: "object-store"},
+			CatalogEntry{
+				Name: "novaNext",
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{Region: "North", PublicURL: "https://next.compute.com/"},
+					Endpoint{Region: "South", PublicURL: "https://south.compute.com/"},
+				},
+			},
+		},
+	}
+
+	errs := catalog.Validate()
+	th.CheckEquals(t, 2, len(errs))
+	th.CheckEquals(t, `Catalog entry "swift" of type "object-store" has no endpoints`, errs[0].Error())
+	th.CheckEquals(t, `Service type "compute" has more than one public endpoint in region "North"`, errs[1].Error())
+}
+
+func TestServiceCatalogSort(t *testing.T) {
+	catalog := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{Name: "nova", Type: "compute", Endpoints: []Endpoint{
+				Endpoint{Region: "RegionTwo", PublicURL: "https://compute2/"},
+				Endpoint{Region: "RegionOne", PublicURL: "https://compute1b/"},
+				Endpoint{Region: "RegionOne", PublicURL: "https://compute1a/"},
+			}},
+			CatalogEntry{Name: "cinder", Type: "volume"},
+			CatalogEntry{ID: "b", Name: "legacy", Type: "compute"},
+			CatalogEntry{ID: "a", Name: "legacy", Type: "compute"},
+		},
+	}
+
+	catalog.Sort()
+
+	th.CheckDeepEquals(t, &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{ID: "a", Name: "legacy", Type: "compute"},
+			CatalogEntry{ID: "b", Name: "legacy", Type: "compute"},
+			CatalogEntry{Name: "nova", Type: "compute", Endpoints: []Endpoint{
+				Endpoint{Region: "RegionOne", PublicURL: "https://compute1a/"},
+				Endpoint{Region: "RegionOne", PublicURL: "https://compute1b/"},
+				Endpoint{Region: "RegionTwo", PublicURL: "https://compute2/"},
+			}},
+			CatalogEntry{Name: "cinder", Type: "volume"},
+		},
+	}, catalog)
+}
+
+const catalogWithExtraEndpointFields = `
+{
+  "access": {
+    "token": { "expires": "2014-01-31T15:30:58Z", "id": "aaaabbbbccccdddd" },
+    "serviceCatalog": [
+      {
+        "type": "compute",
+        "name": "nova",
+        "endpoints": [
+          {
+            "region": "RegionOne",
+            "publicURL": "https://compute.example.com/v2/",
+            "consoleURL": "https://console.example.com/",
+            "weight": 3
+          },
+          { "region": "RegionTwo", "publicURL": "https://compute2.example.com/v2/" }
+        ]
+      }
+    ]
+  }
+}`
+
+func TestExtractServiceCatalogExtraEndpointFields(t *testing.T) {
+	catalog, err := createResultFromJSON(t, catalogWithExtraEndpointFields).ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+
+	endpoints := catalog.Entries[0].Endpoints
+	th.CheckEquals(t, "https://compute.example.com/v2/", endpoints[0].PublicURL)
+	th.CheckDeepEquals(t, map[string]string{"consoleURL": "https://console.example.com/"}, endpoints[0].Extra)
+	th.CheckDeepEquals(t, map[string]string(nil), endpoints[1].Extra)
+
+	streamed, err := DecodeServiceCatalog(strings.NewReader(catalogWithExtraEndpointFields), nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, catalog, streamed)
+
+	serialized, err := catalog.MarshalJSON()
+	th.AssertNoErr(t, err)
+	restored, err := UnmarshalServiceCatalog(serialized)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, catalog, restored)
+}
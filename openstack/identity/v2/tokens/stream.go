@@ -0,0 +1,130 @@
+package tokens
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// DecodeServiceCatalog reads the service catalog from the body of an authentication response, such
+// as the one returned by ProviderClient.Request when no JSONResponse is given. Unlike
+// ExtractServiceCatalog, it decodes the body incrementally, one catalog entry at a time, so the
+// response as a whole is never held in memory.
+//
+// If include is non-nil, only the entries for which it returns true are retained, which keeps memory
+// bounded by the services that are actually needed. With a nil include, the result is identical to
+// that of ExtractServiceCatalog, including for entries whose endpoints are keyed by region.
+func DecodeServiceCatalog(r io.Reader, include func(CatalogEntry) bool) (*ServiceCatalog, error) {
+	decoder := json.NewDecoder(r)
+	catalog := &ServiceCatalog{}
+
+	found, err := seekKey(decoder, "access")
+	if err != nil || !found {
+		return catalog, err
+	}
+	found, err = seekKey(decoder, "serviceCatalog")
+	if err != nil || !found {
+		return catalog, err
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return catalog, nil
+	}
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("Expected the serviceCatalog to be a list, but found %v", token)
+	}
+
+	for decoder.More() {
+		entry, err := decodeCatalogEntry(decoder)
+		if err != nil {
+			return nil, err
+		}
+		if include == nil || include(entry) {
+			catalog.Entries = append(catalog.Entries, entry)
+		}
+	}
+	return catalog, nil
+}
+
+// decodeCatalogEntry decodes the next catalog entry. Entries whose endpoints are keyed by region are
+// converted as ExtractServiceCatalog converts them; see normalizeCatalogEntry.
+func decodeCatalogEntry(decoder *json.Decoder) (CatalogEntry, error) {
+	var entry CatalogEntry
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err != nil {
+		return entry, err
+	}
+
+	var probe struct {
+		Endpoints json.RawMessage `json:"endpoints"`
+	}
+	if err := json.Unmarshal(raw, &probe); err == nil {
+		if trimmed := bytes.TrimSpace(probe.Endpoints); len(trimmed) > 0 && trimmed[0] == '{' {
+			var generic map[string]interface{}
+			if err := json.Unmarshal(raw, &generic); err != nil {
+				return entry, err
+			}
+			if raw, err = json.Marshal(normalizeCatalogEntry(generic)); err != nil {
+				return entry, err
+			}
+		}
+	}
+
+	err := json.Unmarshal(raw, &entry)
+	return entry, err
+}
+
+// seekKey consumes the opening of a JSON object and its members up to the given key, leaving the
+// decoder positioned at that key's value. It reports false if the object doesn't have the key, or if
+// the value is null rather than an object.
+func seekKey(decoder *json.Decoder, key string) (bool, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		return false, nil
+	}
+	if token != json.Delim('{') {
+		return false, fmt.Errorf("Expected an object containing %q, but found %v", key, token)
+	}
+
+	for decoder.More() {
+		name, err := decoder.Token()
+		if err != nil {
+			return false, err
+		}
+		if name == key {
+			return true, nil
+		}
+		if err := skipValue(decoder); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// skipValue consumes the next JSON value from the decoder without retaining it.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
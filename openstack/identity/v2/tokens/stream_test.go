@@ -0,0 +1,86 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestDecodeServiceCatalog(t *testing.T) {
+	expected, err := createResultFromJSON(t, TokenCreationResponse).ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+
+	actual, err := DecodeServiceCatalog(strings.NewReader(TokenCreationResponse), nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, expected, actual)
+	th.CheckDeepEquals(t, ExpectedServiceCatalog, actual)
+}
+
+func TestDecodeServiceCatalogEndpointsByRegion(t *testing.T) {
+	body := `
+    {
+      "access": {
+        "serviceCatalog": [
+          {
+            "name": "nova",
+            "type": "compute",
+            "endpoints": {
+              "RegionTwo": {"publicURL": "https://compute2.example.com/", "versionId": "2"},
+              "RegionOne": [
+                {"publicURL": "https://compute1.example.com/"},
+                {"publicURL": "https://compute1b.example.com/", "region": "RegionOneB"}
+              ]
+            }
+          },
+          {
+            "name": "swift",
+            "type": "object-store",
+            "endpoints": [{"region": "RegionOne", "publicURL": "https://storage.example.com/"}]
+          }
+        ]
+      }
+    }
+  `
+	expected, err := createResultFromJSON(t, body).ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+
+	actual, err := DecodeServiceCatalog(strings.NewReader(body), nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, expected, actual)
+	th.CheckEquals(t, "RegionOneB", actual.Entries[0].Endpoints[1].Region)
+}
+
+func TestDecodeServiceCatalogInclude(t *testing.T) {
+	actual, err := DecodeServiceCatalog(strings.NewReader(TokenCreationResponse), func(entry CatalogEntry) bool {
+		return entry.Type == "else"
+	})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []CatalogEntry{ExpectedServiceCatalog.Entries[1]}, actual.Entries)
+}
+
+func TestDecodeServiceCatalogMissing(t *testing.T) {
+	body := `{"access": {"token": {"id": "aaaabbbbccccdddd", "expires": "2014-01-31T15:30:58Z"}, "serviceCatalog": null}}`
+	expected, err := createResultFromJSON(t, body).ExtractServiceCatalog()
+	th.AssertNoErr(t, err)
+
+	actual, err := DecodeServiceCatalog(strings.NewReader(body), nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, expected, actual)
+
+	actual, err = DecodeServiceCatalog(strings.NewReader(`{"other": [1, {"a": [2]}]}`), nil)
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, &ServiceCatalog{}, actual)
+}
+
+func TestDecodeServiceCatalogMalformed(t *testing.T) {
+	_, err := DecodeServiceCatalog(strings.NewReader(`{"access": {"serviceCatalog": {}}}`), nil)
+	if err == nil {
+		t.Errorf("Expected an error for a serviceCatalog that isn't a list")
+	}
+
+	_, err = DecodeServiceCatalog(strings.NewReader(`{"access": {"serviceCatalog": [{"type": `), nil)
+	if err == nil {
+		t.Errorf("Expected an error for a truncated body")
+	}
+}
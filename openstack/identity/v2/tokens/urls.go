@@ -11,3 +11,8 @@ func CreateURL(client *gophercloud.ServiceClient) string {
 func GetURL(client *gophercloud.ServiceClient, token string) string {
 	return client.ServiceURL("tokens", token)
 }
+
+// RevokeURL generates the URL used to revoke Tokens.
+func RevokeURL(client *gophercloud.ServiceClient, token string) string {
+	return client.ServiceURL("tokens", token)
+}
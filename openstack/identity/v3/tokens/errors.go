@@ -27,6 +27,9 @@ var (
 	// ErrTenantNameProvided indicates that a TenantName was provided but can't be used.
 	ErrTenantNameProvided = unacceptedAttributeErr("TenantName")
 
+	// ErrTrustIDProvided indicates that a TrustID was provided but can't be used.
+	ErrTrustIDProvided = unacceptedAttributeErr("TrustID")
+
 	// ErrUsernameWithToken indicates that a Username was provided, but token authentication is being used instead.
 	ErrUsernameWithToken = redundantWithTokenErr("Username")
 
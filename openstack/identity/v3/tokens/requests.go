@@ -82,6 +82,9 @@ func Create(c *gophercloud.ServiceClient, options gophercloud.AuthOptions, scope
 	if options.TenantName != "" {
 		return createErr(ErrTenantNameProvided)
 	}
+	if options.TrustID != "" {
+		return createErr(ErrTrustIDProvided)
+	}
 
 	if options.Password == "" {
 		if c.TokenID != "" {
@@ -235,7 +238,9 @@ func Create(c *gophercloud.ServiceClient, options gophercloud.AuthOptions, scope
 
 	var result CreateResult
 	var response *http.Response
-	response, result.Err = c.Post(tokenURL(c), req, &result.Body, nil)
+	response, result.Err = c.Post(tokenURL(c), req, &result.Body, &gophercloud.RequestOpts{
+		OmitToken: true,
+	})
 	if result.Err != nil {
 		return result
 	}
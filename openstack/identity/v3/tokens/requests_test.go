@@ -272,6 +272,74 @@ func TestCreateExtractsTokenFromResponse(t *testing.T) {
 	}
 }
 
+func TestCreateExtractsServiceCatalogFromResponse(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	client := gophercloud.ServiceClient{
+		ProviderClient: &gophercloud.ProviderClient{},
+		Endpoint:       testhelper.Endpoint(),
+	}
+
+	testhelper.Mux.HandleFunc("/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("X-Subject-Token", "aaa111")
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{
+			"token": {
+				"expires_at": "2014-10-02T13:45:00.000000Z",
+				"catalog": [
+					{
+						"id": "c1",
+						"name": "nova",
+						"type": "compute",
+						"endpoints": [
+							{
+								"id": "e1",
+								"region": "RegionOne",
+								"interface": "public",
+								"url": "https://compute.example.com/v2/"
+							},
+							{
+								"id": "e2",
+								"region": "RegionOne",
+								"interface": "internal",
+								"url": "http://10.0.0.1/v2/"
+							},
+							{
+								"id": "e3",
+								"region_id": "RegionTwo",
+								"interface": "admin",
+								"url": "http://10.0.0.2/v2/"
+							}
+						]
+					}
+				]
+			}
+		}`)
+	})
+
+	options := gophercloud.AuthOptions{UserID: "me", Password: "shhh"}
+	catalog, err := Create(&client, options, nil).ExtractServiceCatalog()
+	testhelper.AssertNoErr(t, err)
+
+	expected := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{
+				ID:   "c1",
+				Name: "nova",
+				Type: "compute",
+				Endpoints: []Endpoint{
+					Endpoint{ID: "e1", Region: "RegionOne", Interface: "public", URL: "https://compute.example.com/v2/"},
+					Endpoint{ID: "e2", Region: "RegionOne", Interface: "internal", URL: "http://10.0.0.1/v2/"},
+					Endpoint{ID: "e3", Region: "RegionTwo", RegionID: "RegionTwo", Interface: "admin", URL: "http://10.0.0.2/v2/"},
+				},
+			},
+		},
+	}
+	testhelper.CheckDeepEquals(t, expected, catalog)
+}
+
 func TestCreateFailureEmptyAuth(t *testing.T) {
 	authTokenPostErr(t, gophercloud.AuthOptions{}, nil, false, ErrMissingPassword)
 }
@@ -288,6 +356,10 @@ func TestCreateFailureTenantName(t *testing.T) {
 	authTokenPostErr(t, gophercloud.AuthOptions{TenantName: "something"}, nil, false, ErrTenantNameProvided)
 }
 
+func TestCreateFailureTrustID(t *testing.T) {
+	authTokenPostErr(t, gophercloud.AuthOptions{TrustID: "something"}, nil, false, ErrTrustIDProvided)
+}
+
 func TestCreateFailureTokenIDUsername(t *testing.T) {
 	authTokenPostErr(t, gophercloud.AuthOptions{Username: "something"}, nil, true, ErrUsernameWithToken)
 }
@@ -1,6 +1,8 @@
 package tokens
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -16,6 +18,16 @@ type Endpoint struct {
 	Region    string `mapstructure:"region"`
 	Interface string `mapstructure:"interface"`
 	URL       string `mapstructure:"url"`
+
+	// RegionID identifies the endpoint's region on identity services that report it as region_id.
+	// If they don't also report a region, ExtractServiceCatalog copies it into Region.
+	RegionID string `mapstructure:"region_id"`
+}
+
+// Availability interprets the endpoint's Interface, which identity services don't report
+// consistently capitalized, as one of the Availability values used to select endpoints.
+func (e Endpoint) Availability() (gophercloud.Availability, error) {
+	return gophercloud.ParseAvailability(e.Interface)
 }
 
 // CatalogEntry provides a type-safe interface to an Identity API V3 service catalog listing.
@@ -46,6 +58,36 @@ type ServiceCatalog struct {
 	Entries []CatalogEntry
 }
 
+// Sort orders the catalog deterministically, in place: entries by Type, then Name, then ID, and each
+// entry's endpoints by Region, then Interface, then URL. Nothing is dropped or merged, and entries or
+// endpoints that compare equal keep their relative order.
+func (c *ServiceCatalog) Sort() {
+	sort.SliceStable(c.Entries, func(i, j int) bool {
+		a, b := c.Entries[i], c.Entries[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+
+	for _, entry := range c.Entries {
+		endpoints := entry.Endpoints
+		sort.SliceStable(endpoints, func(i, j int) bool {
+			a, b := endpoints[i], endpoints[j]
+			if a.Region != b.Region {
+				return a.Region < b.Region
+			}
+			if ai, bi := strings.ToLower(a.Interface), strings.ToLower(b.Interface); ai != bi {
+				return ai < bi
+			}
+			return a.URL < b.URL
+		})
+	}
+}
+
 // commonResult is the deferred result of a Create or a Get call.
 type commonResult struct {
 	gophercloud.Result
@@ -65,7 +107,10 @@ func (r commonResult) ExtractToken() (*Token, error) {
 
 	var response struct {
 		Token struct {
-			ExpiresAt string `mapstructure:"expires_at"`
+			ExpiresAt string                 `mapstructure:"expires_at"`
+			Project   Project                `mapstructure:"project"`
+			Domain    Domain                 `mapstructure:"domain"`
+			System    map[string]interface{} `mapstructure:"system"`
 		} `mapstructure:"token"`
 	}
 
@@ -79,6 +124,20 @@ func (r commonResult) ExtractToken() (*Token, error) {
 		return nil, err
 	}
 
+	token.Project = response.Token.Project
+	token.Domain = response.Token.Domain
+
+	switch {
+	case token.Project.ID != "":
+		token.Scope = gophercloud.TokenScopeProject
+	case token.Domain.ID != "":
+		token.Scope = gophercloud.TokenScopeDomain
+	case response.Token.System != nil:
+		token.Scope = gophercloud.TokenScopeSystem
+	default:
+		token.Scope = gophercloud.TokenScopeUnscoped
+	}
+
 	// Attempt to parse the timestamp.
 	token.ExpiresAt, err = time.Parse(gophercloud.RFC3339Milli, response.Token.ExpiresAt)
 
@@ -102,6 +161,14 @@ func (result CreateResult) ExtractServiceCatalog() (*ServiceCatalog, error) {
 		return nil, err
 	}
 
+	for _, entry := range response.Token.Entries {
+		for i, endpoint := range entry.Endpoints {
+			if endpoint.Region == "" {
+				entry.Endpoints[i].Region = endpoint.RegionID
+			}
+		}
+	}
+
 	return &ServiceCatalog{Entries: response.Token.Entries}, nil
 }
 
@@ -136,4 +203,34 @@ type Token struct {
 
 	// ExpiresAt is the timestamp at which this token will no longer be accepted.
 	ExpiresAt time.Time
+
+	// Project is the project to which the token is scoped. It's left as the zero value for tokens
+	// that are domain-scoped or unscoped.
+	Project Project
+
+	// Domain is the domain to which the token is scoped. It's left as the zero value for tokens that
+	// are project-scoped or unscoped; a project's own domain is reported in Project.Domain instead.
+	Domain Domain
+
+	// Scope tells which of the project, the domain or the whole system the token is scoped to, or
+	// whether it's unscoped.
+	Scope gophercloud.TokenScope
+}
+
+// Domain identifies a domain to which a token may be scoped.
+type Domain struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+}
+
+// Project identifies a project to which a token may be scoped.
+type Project struct {
+	ID     string `mapstructure:"id"`
+	Name   string `mapstructure:"name"`
+	Domain Domain `mapstructure:"domain"`
+}
+
+// IsDomainScoped reports whether the token is scoped to a domain rather than to a project.
+func (t Token) IsDomainScoped() bool {
+	return t.Domain.ID != ""
 }
@@ -0,0 +1,86 @@
+package tokens
+
+import (
+	"testing"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestServiceCatalogSort(t *testing.T) {
+	catalog := &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{ID: "2", Name: "nova", Type: "compute", Endpoints: []Endpoint{
+				Endpoint{ID: "c", Region: "RegionTwo", Interface: "public", URL: "https://compute2/"},
+				Endpoint{ID: "b", Region: "RegionOne", Interface: "Public", URL: "https://compute1/"},
+				Endpoint{ID: "a", Region: "RegionOne", Interface: "admin", URL: "https://compute1-admin/"},
+			}},
+			CatalogEntry{ID: "1", Name: "cinder", Type: "volume"},
+			CatalogEntry{ID: "3", Name: "legacy", Type: "compute"},
+		},
+	}
+
+	catalog.Sort()
+
+	testhelper.CheckDeepEquals(t, &ServiceCatalog{
+		Entries: []CatalogEntry{
+			CatalogEntry{ID: "3", Name: "legacy", Type: "compute"},
+			CatalogEntry{ID: "2", Name: "nova", Type: "compute", Endpoints: []Endpoint{
+				Endpoint{ID: "a", Region: "RegionOne", Interface: "admin", URL: "https://compute1-admin/"},
+				Endpoint{ID: "b", Region: "RegionOne", Interface: "Public", URL: "https://compute1/"},
+				Endpoint{ID: "c", Region: "RegionTwo", Interface: "public", URL: "https://compute2/"},
+			}},
+			CatalogEntry{ID: "1", Name: "cinder", Type: "volume"},
+		},
+	}, catalog)
+}
+
+func TestExtractTokenScope(t *testing.T) {
+	domainScoped := commonResult{gophercloud.Result{Body: map[string]interface{}{
+		"token": map[string]interface{}{
+			"expires_at": "2014-10-02T13:45:00.000000Z",
+			"domain":     map[string]interface{}{"id": "1789d1", "name": "example.com"},
+		},
+	}}}
+	token, err := domainScoped.ExtractToken()
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, true, token.IsDomainScoped())
+	testhelper.CheckEquals(t, gophercloud.TokenScopeDomain, token.Scope)
+	testhelper.CheckDeepEquals(t, Domain{ID: "1789d1", Name: "example.com"}, token.Domain)
+	testhelper.CheckDeepEquals(t, Project{}, token.Project)
+
+	projectScoped := commonResult{gophercloud.Result{Body: map[string]interface{}{
+		"token": map[string]interface{}{
+			"expires_at": "2014-10-02T13:45:00.000000Z",
+			"project": map[string]interface{}{
+				"id":     "263fd9",
+				"name":   "demo",
+				"domain": map[string]interface{}{"id": "1789d1", "name": "example.com"},
+			},
+		},
+	}}}
+	token, err = projectScoped.ExtractToken()
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, false, token.IsDomainScoped())
+	testhelper.CheckEquals(t, gophercloud.TokenScopeProject, token.Scope)
+	testhelper.CheckDeepEquals(t, Project{ID: "263fd9", Name: "demo", Domain: Domain{ID: "1789d1", Name: "example.com"}}, token.Project)
+
+	systemScoped := commonResult{gophercloud.Result{Body: map[string]interface{}{
+		"token": map[string]interface{}{
+			"expires_at": "2014-10-02T13:45:00.000000Z",
+			"system":     map[string]interface{}{"all": true},
+		},
+	}}}
+	token, err = systemScoped.ExtractToken()
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, gophercloud.TokenScopeSystem, token.Scope)
+
+	unscoped := commonResult{gophercloud.Result{Body: map[string]interface{}{
+		"token": map[string]interface{}{
+			"expires_at": "2014-10-02T13:45:00.000000Z",
+		},
+	}}}
+	token, err = unscoped.ExtractToken()
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, gophercloud.TokenScopeUnscoped, token.Scope)
+}
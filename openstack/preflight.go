@@ -0,0 +1,35 @@
+package openstack
+
+import (
+	"github.com/rackspace/gophercloud"
+)
+
+// PreflightCheck confirms, without changing any state, that options authenticate successfully and
+// that an endpoint can be located for each of the given service types. The base EndpointOpts are
+// used for every lookup, with their Type replaced by each of the types in turn, so set their Region
+// and Availability as the real job would.
+//
+// If authentication fails, its error is returned as-is, since no endpoints can be checked without a
+// catalog. Otherwise every service type is checked, and an *ErrResolveEndpoints describing all of
+// the failures, such as missing services or ambiguous endpoints, is returned if there are any.
+func PreflightCheck(options gophercloud.AuthOptions, base gophercloud.EndpointOpts, types []string) error {
+	provider, err := AuthenticatedClient(options)
+	if err != nil {
+		return err
+	}
+
+	failures := make(map[string]error)
+	for _, serviceType := range types {
+		eo := base.WithType(serviceType)
+		eo.ApplyDefaults(serviceType)
+
+		if _, err := provider.LocateEndpoint(eo); err != nil {
+			failures[serviceType] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ErrResolveEndpoints{Errors: failures}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package openstack
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rackspace/gophercloud"
+	th "github.com/rackspace/gophercloud/testhelper"
+	"github.com/rackspace/gophercloud/testhelper/identity"
+)
+
+func preflightServer() *identity.Server {
+	server := identity.NewServer()
+	server.SetCatalog(
+		identity.Service{Type: "compute", Endpoints: []identity.Endpoint{
+			identity.Endpoint{Region: "RegionOne", PublicURL: "http://compute1.example.com/v2/"},
+			identity.Endpoint{Region: "RegionTwo", PublicURL: "http://compute2.example.com/v2/"},
+		}},
+		identity.Service{Type: "volume", Endpoints: []identity.Endpoint{
+			identity.Endpoint{Region: "RegionOne", PublicURL: "http://volume1.example.com/v1/"},
+		}},
+	)
+	return server
+}
+
+func TestPreflightCheck(t *testing.T) {
+	server := preflightServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	err := PreflightCheck(options, gophercloud.EndpointOpts{Region: "RegionOne"}, []string{"compute", "volume"})
+	th.AssertNoErr(t, err)
+}
+
+func TestPreflightCheckReportsEveryFailure(t *testing.T) {
+	server := preflightServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	err := PreflightCheck(options, gophercloud.EndpointOpts{}, []string{"compute", "volume", "image"})
+
+	resolveErr, ok := err.(*ErrResolveEndpoints)
+	if !ok {
+		t.Fatalf("Expected an *ErrResolveEndpoints, but got %#v", err)
+	}
+	th.CheckEquals(t, 2, len(resolveErr.Errors))
+	if _, ok := resolveErr.Errors["compute"].(*ErrMultipleEndpoints); !ok {
+		t.Errorf("Expected compute to be ambiguous, but got %#v", resolveErr.Errors["compute"])
+	}
+	th.CheckEquals(t, true, errors.Is(resolveErr.Errors["image"], gophercloud.ErrServiceNotInCatalog))
+}
+
+func TestPreflightCheckAuthenticationFailure(t *testing.T) {
+	server := preflightServer()
+	defer server.Close()
+	server.SetResponse(http.StatusUnauthorized, `{"error": {"message": "Invalid credentials", "code": 401}}`)
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "wrong"}
+	err := PreflightCheck(options, gophercloud.EndpointOpts{}, []string{"compute"})
+	if ue, ok := err.(*gophercloud.UnexpectedResponseCodeError); !ok || ue.Actual != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 UnexpectedResponseCodeError, but got %#v", err)
+	}
+}
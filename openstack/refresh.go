@@ -0,0 +1,160 @@
+package openstack
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
+)
+
+const (
+	// DefaultRefreshLead is how long before a token expires a RefreshScheduler that doesn't specify
+	// Lead replaces it.
+	DefaultRefreshLead = 5 * time.Minute
+
+	// DefaultRefreshRetryDelay is how long a RefreshScheduler that doesn't specify RetryDelay waits
+	// before retrying a failed refresh. It doubles with each consecutive failure.
+	DefaultRefreshRetryDelay = 10 * time.Second
+
+	// DefaultRefreshMaxRetryDelay caps the delay between retries of a RefreshScheduler that doesn't
+	// specify MaxRetryDelay.
+	DefaultRefreshMaxRetryDelay = 5 * time.Minute
+)
+
+// RefreshScheduler keeps a ProviderClient's token fresh in the background, replacing it shortly
+// before it expires rather than waiting for a request to be rejected. It's meant for long-running
+// daemons. If a refresh fails, the current token is left in place, since it's still valid, and the
+// refresh is retried with an exponential backoff.
+//
+//	scheduler := openstack.NewRefreshScheduler(provider, options)
+//	scheduler.Start(token)
+//	defer scheduler.Stop()
+type RefreshScheduler struct {
+	// Create acquires a new token. NewRefreshScheduler sets it to authenticate against the v2
+	// identity service with the AuthOptions it's given.
+	Create func() tokens2.CreateResult
+
+	// Lead is how long before the token expires it's replaced.
+	Lead time.Duration
+
+	// RetryDelay is how long to wait before retrying a failed refresh. It doubles with each
+	// consecutive failure, up to MaxRetryDelay.
+	RetryDelay time.Duration
+
+	// MaxRetryDelay caps the delay between retries.
+	MaxRetryDelay time.Duration
+
+	// OnError, if set, is called with the error from each failed refresh, for logging.
+	OnError func(error)
+
+	provider *gophercloud.ProviderClient
+
+	mut  sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRefreshScheduler prepares a RefreshScheduler that replaces provider's token by authenticating
+// with options. Call Start to begin refreshing.
+func NewRefreshScheduler(provider *gophercloud.ProviderClient, options gophercloud.AuthOptions) *RefreshScheduler {
+	// Authenticate with a separate client, so that a rejected refresh doesn't trigger ReauthFunc.
+	identity := NewIdentityV2(&gophercloud.ProviderClient{
+		IdentityBase:     provider.IdentityBase,
+		IdentityEndpoint: provider.IdentityEndpoint,
+		HTTPClient:       provider.HTTPClient,
+		UserAgent:        provider.UserAgent,
+	})
+
+	return &RefreshScheduler{
+		Create: func() tokens2.CreateResult {
+			return tokens2.Create(identity, tokens2.WrapOptions(options))
+		},
+		provider: provider,
+	}
+}
+
+// Start begins refreshing in the background, given the token the provider currently holds. If
+// current is nil, or its expiry is unknown, the first refresh happens at once. It has no effect if
+// the scheduler is already running.
+func (s *RefreshScheduler) Start(current *tokens2.Token) {
+	var expiresAt time.Time
+	if current != nil {
+		expiresAt = current.ExpiresAt
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(expiresAt, s.stop, s.done)
+}
+
+// Stop halts refreshing, waiting for a refresh that's in progress to finish. The provider keeps its
+// current token. It's safe to call Stop more than once.
+func (s *RefreshScheduler) Stop() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+// run refreshes the token until stop is closed.
+func (s *RefreshScheduler) run(expiresAt time.Time, stop, done chan struct{}) {
+	defer close(done)
+
+	lead, retryDelay, maxRetryDelay := s.Lead, s.RetryDelay, s.MaxRetryDelay
+	if lead <= 0 {
+		lead = DefaultRefreshLead
+	}
+	if retryDelay <= 0 {
+		retryDelay = DefaultRefreshRetryDelay
+	}
+	if maxRetryDelay <= 0 {
+		maxRetryDelay = DefaultRefreshMaxRetryDelay
+	}
+
+	// Measure delays against tokens2.Clock, so that they agree with the token's IsExpired.
+	delay := expiresAt.Add(-lead).Sub(tokens2.Clock())
+	backoff := retryDelay
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		token, err := s.Create().ExtractToken()
+		if err != nil {
+			if s.OnError != nil {
+				s.OnError(err)
+			}
+			delay = backoff
+			backoff *= 2
+			if backoff > maxRetryDelay {
+				backoff = maxRetryDelay
+			}
+			continue
+		}
+
+		s.provider.RenewToken(token.ID, token.ExpiresAt)
+		backoff = retryDelay
+
+		// Don't spin if the new token's lifetime is shorter than the lead.
+		delay = token.ExpiresAt.Add(-lead).Sub(tokens2.Clock())
+		if delay < retryDelay {
+			delay = retryDelay
+		}
+	}
+}
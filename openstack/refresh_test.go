@@ -0,0 +1,138 @@
+package openstack
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+	tokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
+	th "github.com/rackspace/gophercloud/testhelper"
+	"github.com/rackspace/gophercloud/testhelper/identity"
+)
+
+// waitForToken polls until provider no longer holds the old token, failing the test after a second.
+func waitForToken(t *testing.T, provider *gophercloud.ProviderClient, old string) {
+	deadline := time.Now().Add(time.Second)
+	for provider.Token() == old {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the token to be replaced, but it's still %q", old)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRefreshSchedulerReplacesToken(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "token-1", provider.Token())
+
+	// Tokens from the fake server last an hour, so this refreshes almost at once.
+	scheduler := NewRefreshScheduler(provider, options)
+	scheduler.Lead = time.Hour - 20*time.Millisecond
+	scheduler.Start(&tokens2.Token{ID: "token-1", ExpiresAt: time.Now().Add(time.Hour)})
+	defer scheduler.Stop()
+
+	waitForToken(t, provider, "token-1")
+	th.CheckEquals(t, "token-2", provider.Token())
+}
+
+func TestRefreshSchedulerKeepsTokenOnFailure(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+
+	var mut sync.Mutex
+	var failures int
+	scheduler := NewRefreshScheduler(provider, options)
+	scheduler.Lead = time.Hour
+	scheduler.RetryDelay = 10 * time.Millisecond
+	scheduler.OnError = func(error) {
+		mut.Lock()
+		defer mut.Unlock()
+		failures++
+	}
+
+	server.SetResponse(500, `{"error": "unavailable"}`)
+	scheduler.Start(&tokens2.Token{ID: "token-1", ExpiresAt: time.Now().Add(time.Hour)})
+	defer scheduler.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mut.Lock()
+		n := failures
+		mut.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected repeated refresh failures, but saw %d", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	th.CheckEquals(t, "token-1", provider.Token())
+
+	server.SetResponse(0, "")
+	waitForToken(t, provider, "token-1")
+}
+
+func TestRefreshSchedulerStop(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+
+	scheduler := NewRefreshScheduler(provider, options)
+	scheduler.Start(&tokens2.Token{ID: "token-1", ExpiresAt: time.Now().Add(time.Hour)})
+	scheduler.Stop()
+	scheduler.Stop()
+
+	th.CheckEquals(t, 1, server.Requests())
+	th.CheckEquals(t, "token-1", provider.Token())
+}
+
+func TestRefreshSchedulerStartsWithoutToken(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+
+	scheduler := NewRefreshScheduler(provider, options)
+	scheduler.Start(nil)
+	defer scheduler.Stop()
+
+	waitForToken(t, provider, "token-1")
+	th.CheckEquals(t, "token-2", provider.Token())
+}
+
+func TestRefreshSchedulerUsesClock(t *testing.T) {
+	server := identity.NewServer()
+	defer server.Close()
+
+	options := gophercloud.AuthOptions{IdentityEndpoint: server.Endpoint(), Username: "me", Password: "swordfish"}
+	provider, err := AuthenticatedClient(options)
+	th.AssertNoErr(t, err)
+
+	// According to the clock, the token has already expired, so it's refreshed at once.
+	clock := tokens2.Clock
+	tokens2.Clock = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	defer func() { tokens2.Clock = clock }()
+
+	scheduler := NewRefreshScheduler(provider, options)
+	scheduler.Start(&tokens2.Token{ID: "token-1", ExpiresAt: time.Now().Add(time.Hour)})
+	defer scheduler.Stop()
+
+	waitForToken(t, provider, "token-1")
+	th.CheckEquals(t, "token-2", provider.Token())
+}
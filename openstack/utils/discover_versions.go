@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/rackspace/gophercloud"
+)
+
+// APIVersion describes one of the API versions published by a service at its unversioned root.
+type APIVersion struct {
+	// ID identifies the version, such as "v2.0".
+	ID string `mapstructure:"id"`
+
+	// Status indicates the version's maturity, such as "CURRENT", "SUPPORTED", "DEPRECATED" or
+	// "EXPERIMENTAL". Services don't agree on its capitalization.
+	Status string `mapstructure:"status"`
+
+	// Links usually include a "self" link to the root of the version's API.
+	Links []gophercloud.Link `mapstructure:"links"`
+}
+
+// DiscoverVersions queries the unversioned root of a service, such as "http://nova:8774/", for the
+// API versions it supports. It understands the list of versions that most services publish, the
+// {"versions": {"values": [...]}} variant published by the identity service, and the single
+// {"version": {...}} document published at a versioned root.
+func DiscoverVersions(client *gophercloud.ServiceClient, rootURL string) ([]APIVersion, error) {
+	var body interface{}
+	_, err := client.Request("GET", rootURL, gophercloud.RequestOpts{
+		JSONResponse: &body,
+		OkCodes:      []int{200, 300},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Versions interface{} `mapstructure:"versions"`
+		Version  *APIVersion `mapstructure:"version"`
+	}
+	if err := mapstructure.Decode(body, &response); err != nil {
+		return nil, err
+	}
+
+	values := response.Versions
+	if wrapped, ok := values.(map[string]interface{}); ok {
+		values = wrapped["values"]
+	}
+	if values != nil {
+		var versions []APIVersion
+		if err := mapstructure.Decode(values, &versions); err != nil {
+			return nil, err
+		}
+		return versions, nil
+	}
+
+	if response.Version != nil {
+		return []APIVersion{*response.Version}, nil
+	}
+	return nil, fmt.Errorf("No versions were published by %s", rootURL)
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestDiscoverVersionsList(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultipleChoices)
+		fmt.Fprintf(w, `
+			{
+				"versions": [
+					{
+						"id": "v2.0",
+						"status": "SUPPORTED",
+						"links": [{ "href": "http://nova:8774/v2/", "rel": "self" }]
+					},
+					{
+						"id": "v2.1",
+						"status": "CURRENT",
+						"links": [{ "href": "http://nova:8774/v2.1/", "rel": "self" }]
+					}
+				]
+			}
+		`)
+	})
+
+	versions, err := DiscoverVersions(pingClient(), testhelper.Endpoint())
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckDeepEquals(t, []APIVersion{
+		APIVersion{ID: "v2.0", Status: "SUPPORTED", Links: []gophercloud.Link{gophercloud.Link{Href: "http://nova:8774/v2/", Rel: "self"}}},
+		APIVersion{ID: "v2.1", Status: "CURRENT", Links: []gophercloud.Link{gophercloud.Link{Href: "http://nova:8774/v2.1/", Rel: "self"}}},
+	}, versions)
+}
+
+func TestDiscoverVersionsValues(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+	setupVersionHandler()
+
+	versions, err := DiscoverVersions(pingClient(), testhelper.Endpoint())
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, 2, len(versions))
+	testhelper.CheckEquals(t, "v3.0", versions[0].ID)
+	testhelper.CheckEquals(t, "stable", versions[0].Status)
+	testhelper.CheckEquals(t, testhelper.Server.URL+"/v2.0", versions[1].Links[0].Href)
+}
+
+func TestDiscoverVersionsSingle(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/v2.1/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `
+			{
+				"version": {
+					"id": "v2.1",
+					"status": "CURRENT",
+					"links": [{ "href": "http://nova:8774/v2.1/", "rel": "self" }]
+				}
+			}
+		`)
+	})
+
+	versions, err := DiscoverVersions(pingClient(), testhelper.Endpoint()+"v2.1/")
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckDeepEquals(t, []APIVersion{
+		APIVersion{ID: "v2.1", Status: "CURRENT", Links: []gophercloud.Link{gophercloud.Link{Href: "http://nova:8774/v2.1/", Rel: "self"}}},
+	}, versions)
+}
+
+func TestDiscoverVersionsNone(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{}`)
+	})
+
+	_, err := DiscoverVersions(pingClient(), testhelper.Endpoint())
+	testhelper.CheckEquals(t, "No versions were published by "+testhelper.Endpoint(), err.Error())
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+)
+
+// PingEndpoint checks that the endpoint at url is reachable from here by issuing a GET request to
+// it. Any response other than a server error counts as success, since a healthy endpoint may well
+// reject an unauthenticated request to an arbitrary resource. Use it to detect catalogs that
+// advertise stale endpoints, or internal endpoints that can't be reached from where the program runs.
+//
+// The request is abandoned after timeout, regardless of any timeout configured on the client's
+// HTTPClient. It's made with the client's transport, so proxy and TLS settings are honored, but it
+// carries no credentials.
+func PingEndpoint(client *gophercloud.ServiceClient, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return pingEndpoint(ctx, client, url)
+}
+
+// FastestEndpoint pings each of urls concurrently, as PingEndpoint does, and returns the first to
+// respond successfully. The remaining pings are cancelled as soon as there's a winner. Pass it the
+// candidates returned by V2EndpointURLs or V3EndpointURLs to choose the lowest-latency endpoint.
+//
+// Every ping is abandoned after timeout. If none of them succeeds, an *ErrEndpointsUnreachable
+// describing each failure is returned.
+func FastestEndpoint(client *gophercloud.ServiceClient, urls []string, timeout time.Duration) (string, error) {
+	if len(urls) == 0 {
+		return "", ErrNoEndpoints
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type outcome struct {
+		url string
+		err error
+	}
+	outcomes := make(chan outcome, len(urls))
+	for _, url := range urls {
+		go func(url string) {
+			outcomes <- outcome{url, pingEndpoint(ctx, client, url)}
+		}(url)
+	}
+
+	failures := make(map[string]error, len(urls))
+	for range urls {
+		o := <-outcomes
+		if o.err == nil {
+			return o.url, nil
+		}
+		failures[o.url] = o.err
+	}
+	return "", &ErrEndpointsUnreachable{Errors: failures}
+}
+
+// ErrNoEndpoints is returned by FastestEndpoint when it isn't given any URLs to choose from.
+var ErrNoEndpoints = errors.New("No endpoints were provided to choose from.")
+
+// ErrEndpointsUnreachable is returned by FastestEndpoint when none of the endpoints responded
+// successfully. Errors maps each endpoint URL to the reason its ping failed.
+type ErrEndpointsUnreachable struct {
+	Errors map[string]error
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (e *ErrEndpointsUnreachable) Error() string {
+	urls := make([]string, 0, len(e.Errors))
+	for url := range e.Errors {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	reasons := make([]string, 0, len(urls))
+	for _, url := range urls {
+		reasons = append(reasons, e.Errors[url].Error())
+	}
+	return fmt.Sprintf("None of the %d endpoints responded successfully: %s", len(urls), strings.Join(reasons, "; "))
+}
+
+// pingEndpoint issues the request on behalf of PingEndpoint and FastestEndpoint.
+func pingEndpoint(ctx context.Context, client *gophercloud.ServiceClient, url string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", client.UserAgent.Join())
+
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Endpoint %s is unreachable: %s", url, err)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("Endpoint %s is unhealthy: it responded with %d", url, resp.StatusCode)
+	}
+	return nil
+}
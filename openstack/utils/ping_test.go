@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/testhelper"
+)
+
+func pingClient() *gophercloud.ServiceClient {
+	return &gophercloud.ServiceClient{ProviderClient: &gophercloud.ProviderClient{}, Endpoint: testhelper.Endpoint()}
+}
+
+func TestPingEndpoint(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/healthy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	testhelper.Mux.HandleFunc("/unauthorized", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	testhelper.AssertNoErr(t, PingEndpoint(pingClient(), testhelper.Endpoint()+"healthy", time.Second))
+	testhelper.AssertNoErr(t, PingEndpoint(pingClient(), testhelper.Endpoint()+"unauthorized", time.Second))
+}
+
+func TestPingEndpointServerError(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	err := PingEndpoint(pingClient(), testhelper.Endpoint(), time.Second)
+	testhelper.CheckEquals(t, "Endpoint "+testhelper.Endpoint()+" is unhealthy: it responded with 503", err.Error())
+}
+
+func TestPingEndpointTimeout(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	err := PingEndpoint(pingClient(), testhelper.Endpoint(), 10*time.Millisecond)
+	if err == nil || !strings.HasPrefix(err.Error(), "Endpoint "+testhelper.Endpoint()+" is unreachable: ") {
+		t.Errorf("Expected the endpoint to be unreachable, but got %v", err)
+	}
+}
+
+func TestFastestEndpoint(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	cancelled := make(chan struct{})
+	testhelper.Mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+	})
+	testhelper.Mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	testhelper.Mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	base := testhelper.Endpoint()
+	actual, err := FastestEndpoint(pingClient(), []string{base + "slow", base + "broken", base + "fast"}, time.Minute)
+	testhelper.AssertNoErr(t, err)
+	testhelper.CheckEquals(t, base+"fast", actual)
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected the slow ping to be cancelled once a winner was found")
+	}
+}
+
+func TestFastestEndpointAllFail(t *testing.T) {
+	testhelper.SetupHTTP()
+	defer testhelper.TeardownHTTP()
+
+	testhelper.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	base := testhelper.Endpoint()
+	_, err := FastestEndpoint(pingClient(), []string{base + "b", base + "a"}, time.Second)
+	unreachable, ok := err.(*ErrEndpointsUnreachable)
+	if !ok {
+		t.Fatalf("Expected an *ErrEndpointsUnreachable, but got %#v", err)
+	}
+	testhelper.CheckEquals(t, 2, len(unreachable.Errors))
+	testhelper.CheckEquals(t, "None of the 2 endpoints responded successfully: "+
+		"Endpoint "+base+"a is unhealthy: it responded with 502; "+
+		"Endpoint "+base+"b is unhealthy: it responded with 502", err.Error())
+
+	_, err = FastestEndpoint(pingClient(), nil, time.Second)
+	testhelper.CheckEquals(t, ErrNoEndpoints, err)
+}
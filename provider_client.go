@@ -2,17 +2,29 @@ package gophercloud
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DefaultUserAgent is the default User-Agent string set in the request header.
 const DefaultUserAgent = "gophercloud/1.0.0"
 
+// DefaultMaxResponseBytes is the size limit applied to the response bodies gophercloud reads itself,
+// unless configured otherwise. It comfortably fits the service catalogs of large clouds.
+const DefaultMaxResponseBytes = 64 << 20
+
+// DefaultExpirySkew is how long before their stated expiry tokens are treated as expired, unless
+// configured otherwise, to tolerate differences between the local clock and the identity service's.
+const DefaultExpirySkew = 30 * time.Second
+
 // UserAgent represents a User-Agent header.
 type UserAgent struct {
 	// prepend is the slice of User-Agent strings to prepend to DefaultUserAgent.
@@ -51,7 +63,8 @@ type ProviderClient struct {
 	// than querying versions first.
 	IdentityEndpoint string
 
-	// TokenID is the ID of the most recently issued valid token.
+	// TokenID is the ID of the most recently issued valid token. Once the client is in use by
+	// several goroutines, replace it with SetToken rather than by assigning to it directly.
 	TokenID string
 
 	// EndpointLocator describes how this provider discovers the endpoints for
@@ -67,16 +80,179 @@ type ProviderClient struct {
 	// ReauthFunc is the function used to re-authenticate the user if the request
 	// fails with a 401 HTTP response code. This a needed because there may be multiple
 	// authentication functions for different Identity service versions.
+	//
+	// It's invoked at most once per request, and never for requests that were
+	// issued without a token. Concurrent requests that are rejected with the same
+	// token share a single invocation. It should leave the current token in place
+	// until it stores the new one, since other requests keep using it meanwhile,
+	// and issue its own authentication request with RequestOpts.OmitToken.
 	ReauthFunc func() error
+
+	// EndpointOverrides maps service types to the URLs that should be used for them, instead of the
+	// endpoints advertised by the service catalog. An override wins over any catalog entry, so it's
+	// useful for pointing a service at a local mock or a sidecar.
+	EndpointOverrides map[string]string
+
+	// ExpirySkew is how long before its expiry the client's token is treated as expired. When a
+	// request is about to be sent with such a token, the client re-authenticates first, if it has a
+	// ReauthFunc, rather than waiting for the token to be rejected. This only happens when the
+	// token's expiry is known, as it is after authenticating with a provider's Authenticate function.
+	// If zero, DefaultExpirySkew is used; a negative value disables proactive re-authentication.
+	ExpirySkew time.Duration
+
+	// MaxResponseBytes limits the size of the response bodies that the client reads itself: those
+	// parsed into a JSONResponse, such as tokens and service catalogs, and those of unexpected
+	// responses, which are truncated. A body parsed as JSON that exceeds it yields a
+	// *ResponseTooLargeError. Bodies handed to the caller unread, like object downloads, aren't
+	// limited. If zero, DefaultMaxResponseBytes is used; a negative value removes the limit.
+	MaxResponseBytes int64
+
+	// CatalogTTL is how long a service catalog stored by SetAuthentication is kept when the client
+	// re-authenticates with its ReauthFunc. While the catalog is younger than CatalogTTL, only the
+	// token is replaced, and the providers skip parsing the catalog in the identity service's
+	// response. Use RefreshCatalog to replace it sooner. If zero, the catalog is replaced every time.
+	CatalogTTL time.Duration
+
+	// LogBodies includes request and response bodies in the messages sent to the Logger set with
+	// SetLogger. Passwords and token IDs are redacted from them.
+	LogBodies bool
+
+	// reauthmut serializes invocations of ReauthFunc.
+	reauthmut sync.Mutex
+
+	// tokenmut guards TokenID, tokenExpiresAt and catalog against concurrent replacement.
+	tokenmut sync.RWMutex
+
+	// tokenExpiresAt and catalog describe the current token, if it was set by SetAuthentication.
+	// catalogSetAt is when the catalog was stored, or the zero time once RefreshCatalog expires it.
+	tokenExpiresAt time.Time
+	catalog        interface{}
+	catalogSetAt   time.Time
+
+	// logger receives a description of each request, if set.
+	logger Logger
+
+	// observer is notified about the outcome of each request, if set.
+	observer RequestObserver
 }
 
 // AuthenticatedHeaders returns a map of HTTP headers that are common for all
 // authenticated service requests.
 func (client *ProviderClient) AuthenticatedHeaders() map[string]string {
-	if client.TokenID == "" {
+	token := client.Token()
+	if token == "" {
 		return map[string]string{}
 	}
-	return map[string]string{"X-Auth-Token": client.TokenID}
+	return map[string]string{"X-Auth-Token": token}
+}
+
+// Token returns the ID of the token that requests are currently authenticated with.
+func (client *ProviderClient) Token() string {
+	client.tokenmut.RLock()
+	defer client.tokenmut.RUnlock()
+	return client.TokenID
+}
+
+// SetToken replaces the token that requests are authenticated with. It's safe to call while other
+// goroutines are issuing requests with the client; each request uses either the old token or the
+// new one. The new token's expiry is unknown, so TokenExpiresAt reports the zero time until
+// SetAuthentication is called.
+func (client *ProviderClient) SetToken(id string) {
+	client.tokenmut.Lock()
+	defer client.tokenmut.Unlock()
+	client.TokenID = id
+	client.tokenExpiresAt = time.Time{}
+}
+
+// SetAuthentication records the outcome of authenticating: the token that requests are to be
+// authenticated with, when it expires, and the service catalog issued alongside it. The providers'
+// Authenticate functions call it, replacing what an earlier authentication stored. Like SetToken,
+// it's safe to call while other goroutines are issuing requests with the client.
+func (client *ProviderClient) SetAuthentication(tokenID string, expiresAt time.Time, catalog interface{}) {
+	client.tokenmut.Lock()
+	defer client.tokenmut.Unlock()
+	client.TokenID = tokenID
+	client.tokenExpiresAt = expiresAt
+	client.catalog = catalog
+	client.catalogSetAt = time.Now()
+}
+
+// RenewToken replaces the token that requests are authenticated with and its expiry, like
+// SetAuthentication, but keeps the service catalog that's already stored, along with its age.
+func (client *ProviderClient) RenewToken(tokenID string, expiresAt time.Time) {
+	client.tokenmut.Lock()
+	defer client.tokenmut.Unlock()
+	client.TokenID = tokenID
+	client.tokenExpiresAt = expiresAt
+}
+
+// CatalogFresh reports whether the stored service catalog is younger than CatalogTTL, in which case
+// re-authenticating keeps it rather than replacing it.
+func (client *ProviderClient) CatalogFresh() bool {
+	client.tokenmut.RLock()
+	defer client.tokenmut.RUnlock()
+	return client.catalog != nil && client.CatalogTTL > 0 && !client.catalogSetAt.IsZero() &&
+		time.Since(client.catalogSetAt) < client.CatalogTTL
+}
+
+// RefreshCatalog replaces the stored service catalog now, regardless of CatalogTTL, by
+// re-authenticating with the ReauthFunc. It returns ErrNoReauthFunc if the client has none, in
+// which case it must be authenticated again explicitly.
+func (client *ProviderClient) RefreshCatalog() error {
+	if client.ReauthFunc == nil {
+		return ErrNoReauthFunc
+	}
+
+	client.reauthmut.Lock()
+	defer client.reauthmut.Unlock()
+
+	client.tokenmut.Lock()
+	client.catalogSetAt = time.Time{}
+	client.tokenmut.Unlock()
+
+	return client.ReauthFunc()
+}
+
+// TokenExpiresAt returns the time at which the current token expires, or the zero time if it isn't
+// known, as when the token was provided with SetToken.
+func (client *ProviderClient) TokenExpiresAt() time.Time {
+	client.tokenmut.RLock()
+	defer client.tokenmut.RUnlock()
+	return client.tokenExpiresAt
+}
+
+// ServiceCatalog returns the service catalog stored by the most recent SetAuthentication, or nil if
+// there's none. For the OpenStack and Rackspace providers, it's the *ServiceCatalog of the identity
+// v2 or v3 tokens package, depending on which version of the identity service was used.
+func (client *ProviderClient) ServiceCatalog() interface{} {
+	client.tokenmut.RLock()
+	defer client.tokenmut.RUnlock()
+	return client.catalog
+}
+
+// tokenExpired reports whether the current token's expiry is known and, brought forward by the
+// ExpirySkew, has passed.
+func (client *ProviderClient) tokenExpired() bool {
+	skew := client.ExpirySkew
+	if skew < 0 {
+		return false
+	}
+	if skew == 0 {
+		skew = DefaultExpirySkew
+	}
+
+	expiresAt := client.TokenExpiresAt()
+	return !expiresAt.IsZero() && !time.Now().Add(skew).Before(expiresAt)
+}
+
+// LocateEndpoint discovers the URL of the endpoint described by eo. If EndpointOverrides has an entry
+// for its Type, that URL is returned as-is without consulting the EndpointLocator, so neither
+// ambiguity nor missing catalog entries are reported for it.
+func (client *ProviderClient) LocateEndpoint(eo EndpointOpts) (string, error) {
+	if url, ok := client.EndpointOverrides[eo.Type]; ok {
+		return NormalizeURL(url), nil
+	}
+	return client.EndpointLocator(eo)
 }
 
 // RequestOpts customizes the behavior of the provider.Request() method.
@@ -100,8 +276,27 @@ type RequestOpts struct {
 	// provided with a blank value (""), that header will be *omitted* instead: use this to suppress
 	// the default Accept header or an inferred Content-Type, for example.
 	MoreHeaders map[string]string
+
+	// Context, if provided, governs the request's lifetime: the request is abandoned with the
+	// context's error once it's cancelled or its deadline passes.
+	Context context.Context
+
+	// TokenID, if provided, authenticates this request instead of the client's own token, for
+	// one-off calls such as impersonation. The client is left unchanged. Since its token isn't used,
+	// a 401 response is returned as an error without re-authenticating the client.
+	TokenID string
+
+	// OmitToken, if set, sends the request without the client's token, as the identity service's
+	// authentication requests are sent. A 401 response is returned as an error without
+	// re-authenticating the client, so a ReauthFunc may issue such requests itself while the
+	// client's current token stays in place for other requests.
+	OmitToken bool
 }
 
+// ErrNoReauthFunc is returned by RefreshCatalog if the client has no ReauthFunc to re-authenticate
+// with, as when it was authenticated without AllowReauth.
+var ErrNoReauthFunc = errors.New("The client can't re-authenticate without a ReauthFunc; authenticate it again instead.")
+
 // UnexpectedResponseCodeError is returned by the Request method when a response code other than
 // those listed in OkCodes is encountered.
 type UnexpectedResponseCodeError struct {
@@ -110,21 +305,121 @@ type UnexpectedResponseCodeError struct {
 	Expected []int
 	Actual   int
 	Body     []byte
+
+	// RequestID is the ID the service assigned to the request, if it reported one.
+	RequestID string
+
+	// Fault is the error the service described in Body, if Body has the identity service's
+	// {"error": {"code", "title", "message"}} form. Otherwise it's nil and only Body is available.
+	Fault *Fault
+}
+
+// Fault is an error as the identity service describes it in the body of an unsuccessful response.
+// It's also reachable from an *UnexpectedResponseCodeError with errors.As.
+type Fault struct {
+	Code    int    `json:"code"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (f *Fault) Error() string {
+	return fmt.Sprintf("%d %s: %s", f.Code, f.Title, f.Message)
+}
+
+// parseFault extracts the Fault from a response body, or returns nil if the body doesn't
+// describe one.
+func parseFault(body []byte) *Fault {
+	var envelope struct {
+		Error *Fault `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Error == nil {
+		return nil
+	}
+	if envelope.Error.Title == "" && envelope.Error.Message == "" {
+		return nil
+	}
+	return envelope.Error
 }
 
 func (err *UnexpectedResponseCodeError) Error() string {
+	if err.RequestID != "" {
+		return fmt.Sprintf(
+			"Expected HTTP response code %v when accessing [%s %s], but got %d instead (request ID %s)\n%s",
+			err.Expected, err.Method, err.URL, err.Actual, err.RequestID, err.Body,
+		)
+	}
 	return fmt.Sprintf(
 		"Expected HTTP response code %v when accessing [%s %s], but got %d instead\n%s",
 		err.Expected, err.Method, err.URL, err.Actual, err.Body,
 	)
 }
 
+// Unwrap returns the Fault the service described, if any, so that errors.As can retrieve it.
+func (err *UnexpectedResponseCodeError) Unwrap() error {
+	if err.Fault == nil {
+		return nil
+	}
+	return err.Fault
+}
+
+// IsTokenExpired reports whether the request was rejected because the token it carried has expired,
+// as opposed to being invalid for some other reason. It inspects the fault in the response body, so
+// it returns false for a 401 whose cause the identity service didn't spell out.
+func (err *UnexpectedResponseCodeError) IsTokenExpired() bool {
+	if err.Actual != http.StatusUnauthorized {
+		return false
+	}
+
+	fault := err.Fault
+	if fault == nil {
+		fault = parseFault(err.Body)
+	}
+	if fault == nil {
+		return false
+	}
+	if fault.Code != 0 && fault.Code != http.StatusUnauthorized {
+		return false
+	}
+
+	title, message := strings.ToLower(fault.Title), strings.ToLower(fault.Message)
+	return strings.Contains(title, "expired") || strings.Contains(message, "expired")
+}
+
+// IsTokenExpired reports whether err is an *UnexpectedResponseCodeError caused by an expired
+// token. Use it to re-authenticate specifically when a token has expired, rather than on any 401.
+func IsTokenExpired(err error) bool {
+	if err, ok := err.(*UnexpectedResponseCodeError); ok {
+		return err.IsTokenExpired()
+	}
+	return false
+}
+
 var applicationJSON = "application/json"
 
 // Request performs an HTTP request using the ProviderClient's current HTTPClient. An authentication
-// header will automatically be provided.
+// header will automatically be provided. If the request is rejected with a 401 and a ReauthFunc is
+// available, the client re-authenticates and replays the request once.
 func (client *ProviderClient) Request(method, url string, options RequestOpts) (*http.Response, error) {
+	return client.request(method, url, options, true)
+}
+
+// reauthenticate invokes ReauthFunc on behalf of a request that was rejected while using
+// previousToken. If another request has already replaced that token in the meantime, it returns
+// immediately so that the caller can simply retry with the new one.
+func (client *ProviderClient) reauthenticate(previousToken string) error {
+	client.reauthmut.Lock()
+	defer client.reauthmut.Unlock()
+
+	if client.Token() != previousToken {
+		return nil
+	}
+	return client.ReauthFunc()
+}
+
+func (client *ProviderClient) request(method, url string, options RequestOpts, allowReauth bool) (*http.Response, error) {
 	var body io.ReadSeeker
+	var rendered []byte
 	var contentType *string
 
 	// Derive the content body by either encoding an arbitrary object as JSON, or by taking a provided
@@ -134,7 +429,8 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 			panic("Please provide only one of JSONBody or RawBody to gophercloud.Request().")
 		}
 
-		rendered, err := json.Marshal(options.JSONBody)
+		var err error
+		rendered, err = json.Marshal(options.JSONBody)
 		if err != nil {
 			return nil, err
 		}
@@ -153,6 +449,22 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 		return nil, err
 	}
 
+	// net/http can only rewind a few kinds of bodies itself. Since a RawBody is an io.ReadSeeker, any
+	// of them can be rewound, so that transports that retry, like RetryTransport, can replay it.
+	if body != nil && req.GetBody == nil {
+		if start, err := body.Seek(0, io.SeekCurrent); err == nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				if _, err := body.Seek(start, io.SeekStart); err != nil {
+					return nil, err
+				}
+				return ioutil.NopCloser(body), nil
+			}
+		}
+	}
+	if options.Context != nil {
+		req = req.WithContext(options.Context)
+	}
+
 	// Populate the request headers. Apply options.MoreHeaders last, to give the caller the chance to
 	// modify or omit any header.
 	if contentType != nil {
@@ -160,8 +472,26 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 	}
 	req.Header.Set("Accept", applicationJSON)
 
-	for k, v := range client.AuthenticatedHeaders() {
-		req.Header.Add(k, v)
+	// Re-authenticating the client can't help a request that doesn't use its token.
+	if options.TokenID != "" || options.OmitToken {
+		allowReauth = false
+	}
+
+	// Re-authenticate ahead of time rather than sending a token that's about to expire.
+	if allowReauth && client.ReauthFunc != nil && client.tokenExpired() {
+		if err := client.reauthenticate(client.Token()); err != nil {
+			return nil, err
+		}
+	}
+
+	var prereqtok string
+	if options.TokenID != "" {
+		req.Header.Set("X-Auth-Token", options.TokenID)
+	} else if !options.OmitToken {
+		prereqtok = client.Token()
+		if prereqtok != "" {
+			req.Header.Set("X-Auth-Token", prereqtok)
+		}
 	}
 
 	// Set the User-Agent header
@@ -178,36 +508,32 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 	}
 
 	// Issue the request.
+	client.logRequest(req, rendered)
+	start := time.Now()
 	resp, err := client.HTTPClient.Do(req)
 	if err != nil {
+		client.observe(method, url, 0, time.Since(start))
 		return nil, err
 	}
+	client.observe(method, url, resp.StatusCode, time.Since(start))
+	client.logResponse(req, resp)
+
+	// Re-authenticate and replay the request once if the token was rejected. Requests made without the
+	// client's token, such as the authentication request issued by ReauthFunc itself, are never
+	// retried. The replayed request picks up the new token from the client.
+	if resp.StatusCode == http.StatusUnauthorized && allowReauth && client.ReauthFunc != nil && prereqtok != "" {
+		err = client.reauthenticate(prereqtok)
+		if err != nil {
+			return nil, fmt.Errorf("Error trying to re-authenticate: %s", err)
+		}
 
-	if resp.StatusCode == http.StatusUnauthorized {
-		if client.ReauthFunc != nil {
-			// make sure ReauthFunc only exec one time, or will occur endless recursive loop when admin reauth fail
-			execFunc := client.ReauthFunc
-			client.ReauthFunc = nil
-			err = execFunc()
-			client.ReauthFunc = execFunc
-			if err != nil {
-				return nil, fmt.Errorf("Error trying to re-authenticate: %s", err)
-			}
-
-			if options.MoreHeaders != nil {
-				options.MoreHeaders["X-Auth-Token"] = client.TokenID
-			} else {
-				options.MoreHeaders = client.AuthenticatedHeaders()
-			}
-
-			if options.RawBody != nil {
-				options.RawBody.Seek(0, 0)
-			}
-			resp.Body.Close()
-			resp, err = client.Request(method, url, options)
-			if err != nil {
-				return nil, fmt.Errorf("Successfully re-authenticated, but got error executing request: %s", err)
-			}
+		if options.RawBody != nil {
+			options.RawBody.Seek(0, 0)
+		}
+		resp.Body.Close()
+		resp, err = client.request(method, url, options, false)
+		if err != nil {
+			return nil, fmt.Errorf("Successfully re-authenticated, but got error executing request: %s", err)
 		}
 	}
 
@@ -225,21 +551,28 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 		}
 	}
 	if !ok {
-		body, _ := ioutil.ReadAll(resp.Body)
+		body, _ := ioutil.ReadAll(client.limitBody(resp.Body))
 		resp.Body.Close()
 		return resp, &UnexpectedResponseCodeError{
-			URL:      url,
-			Method:   method,
-			Expected: options.OkCodes,
-			Actual:   resp.StatusCode,
-			Body:     body,
+			URL:       url,
+			Method:    method,
+			Expected:  options.OkCodes,
+			Actual:    resp.StatusCode,
+			Body:      body,
+			RequestID: RequestID(resp.Header),
+			Fault:     parseFault(body),
 		}
 	}
 
 	// Parse the response body as JSON, if requested to do so.
 	if options.JSONResponse != nil {
 		defer resp.Body.Close()
-		if err := json.NewDecoder(resp.Body).Decode(options.JSONResponse); err != nil {
+		body := client.limitBody(resp.Body)
+		err := json.NewDecoder(body).Decode(options.JSONResponse)
+		if limited, ok := body.(*io.LimitedReader); ok && limited.N == 0 {
+			return nil, &ResponseTooLargeError{URL: url, Limit: client.maxResponseBytes()}
+		}
+		if err != nil {
 			return nil, err
 		}
 	}
@@ -247,6 +580,36 @@ func (client *ProviderClient) Request(method, url string, options RequestOpts) (
 	return resp, nil
 }
 
+// maxResponseBytes resolves MaxResponseBytes, returning a negative value if there's no limit.
+func (client *ProviderClient) maxResponseBytes() int64 {
+	if client.MaxResponseBytes == 0 {
+		return DefaultMaxResponseBytes
+	}
+	return client.MaxResponseBytes
+}
+
+// limitBody wraps a response body so that it yields at most one byte more than MaxResponseBytes,
+// which is enough to tell that the limit was exceeded. Without a limit, body is returned as-is.
+func (client *ProviderClient) limitBody(body io.Reader) io.Reader {
+	limit := client.maxResponseBytes()
+	if limit < 0 {
+		return body
+	}
+	return &io.LimitedReader{R: body, N: limit + 1}
+}
+
+// ResponseTooLargeError is returned by the Request method when a response body that it reads
+// itself exceeds the client's MaxResponseBytes.
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+// Error yields a useful diagnostic for debugging purposes.
+func (err *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("The response from %s exceeded the limit of %d bytes", err.URL, err.Limit)
+}
+
 func defaultOkCodes(method string) []int {
 	switch {
 	case method == "GET":
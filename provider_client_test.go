@@ -1,7 +1,14 @@
 package gophercloud
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	th "github.com/rackspace/gophercloud/testhelper"
 )
@@ -33,3 +40,345 @@ func TestUserAgent(t *testing.T) {
 	actual = p.UserAgent.Join()
 	th.CheckEquals(t, expected, actual)
 }
+
+func TestUserAgentIsSent(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		th.TestHeader(t, r, "User-Agent", "my-app/1.2 "+DefaultUserAgent)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	p := &ProviderClient{}
+	p.UserAgent.Prepend("my-app/1.2")
+	_, err := p.Request("GET", th.Endpoint(), RequestOpts{})
+	th.AssertNoErr(t, err)
+}
+
+func TestReauthenticateOn401(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Auth-Token") != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var reauths int32
+	p := &ProviderClient{TokenID: "stale"}
+	p.ReauthFunc = func() error {
+		atomic.AddInt32(&reauths, 1)
+		p.TokenID = "fresh"
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+			th.CheckNoErr(t, err)
+		}()
+	}
+	wg.Wait()
+
+	th.CheckEquals(t, int32(1), atomic.LoadInt32(&reauths))
+}
+
+func TestReauthenticateOnlyOnce(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	reauths := 0
+	p := &ProviderClient{TokenID: "stale"}
+	p.ReauthFunc = func() error {
+		reauths++
+		p.TokenID = fmt.Sprintf("token%d", reauths)
+		return nil
+	}
+
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	if err == nil {
+		t.Fatalf("Expected an error after the replayed request was rejected")
+	}
+	th.CheckEquals(t, 1, reauths)
+}
+
+func TestReauthenticateBeforeExpiry(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var tokens []string
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Auth-Token"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	reauths := 0
+	p := &ProviderClient{}
+	p.SetAuthentication("expiring", time.Now().Add(10*time.Second), nil)
+	p.ReauthFunc = func() error {
+		reauths++
+		p.SetAuthentication("fresh", time.Now().Add(time.Hour), nil)
+		return nil
+	}
+
+	// The token expires within the default skew, so it's replaced before the first request.
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+	_, err = p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"fresh", "fresh"}, tokens)
+	th.CheckEquals(t, 1, reauths)
+
+	tokens = nil
+	p.SetAuthentication("expiring", time.Now().Add(10*time.Second), nil)
+	p.ExpirySkew = 5 * time.Second
+	_, err = p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	p.ExpirySkew = -1
+	p.SetAuthentication("expiring", time.Now().Add(-time.Second), nil)
+	_, err = p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	th.CheckDeepEquals(t, []string{"expiring", "expiring"}, tokens)
+	th.CheckEquals(t, 1, reauths)
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"padding": "%s"}`, strings.Repeat("x", 100))
+	})
+	th.Mux.HandleFunc("/failure", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	})
+
+	var body interface{}
+	p := &ProviderClient{MaxResponseBytes: 50}
+	_, err := p.Request("GET", th.Endpoint()+"large", RequestOpts{JSONResponse: &body})
+	tooLarge, ok := err.(*ResponseTooLargeError)
+	if !ok {
+		t.Fatalf("Expected a *ResponseTooLargeError, but got %#v", err)
+	}
+	th.CheckEquals(t, int64(50), tooLarge.Limit)
+	th.CheckEquals(t, "The response from "+th.Endpoint()+"large exceeded the limit of 50 bytes", err.Error())
+
+	_, err = p.Request("GET", th.Endpoint()+"failure", RequestOpts{})
+	unexpected, ok := err.(*UnexpectedResponseCodeError)
+	if !ok {
+		t.Fatalf("Expected an *UnexpectedResponseCodeError, but got %#v", err)
+	}
+	th.CheckEquals(t, true, len(unexpected.Body) <= 51)
+
+	p.MaxResponseBytes = 200
+	_, err = p.Request("GET", th.Endpoint()+"large", RequestOpts{JSONResponse: &body})
+	th.AssertNoErr(t, err)
+
+	p.MaxResponseBytes = -1
+	_, err = p.Request("GET", th.Endpoint()+"large", RequestOpts{JSONResponse: &body})
+	th.AssertNoErr(t, err)
+}
+
+func TestRequestTokenOverride(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var tokens []string
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("X-Auth-Token"))
+		if r.Header.Get("X-Auth-Token") == "revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	reauths := 0
+	p := &ProviderClient{TokenID: "shared"}
+	p.ReauthFunc = func() error {
+		reauths++
+		return nil
+	}
+
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{TokenID: "impersonated"})
+	th.AssertNoErr(t, err)
+	_, err = p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	_, err = p.Request("GET", th.Endpoint()+"route", RequestOpts{TokenID: "revoked"})
+	if err == nil {
+		t.Fatalf("Expected an error for the rejected override token")
+	}
+
+	th.CheckDeepEquals(t, []string{"impersonated", "shared", "revoked"}, tokens)
+	th.CheckEquals(t, "shared", p.Token())
+	th.CheckEquals(t, 0, reauths)
+}
+
+func TestIsTokenExpired(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/expired", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"error": {"message": "The token has expired.", "code": 401, "title": "Unauthorized"}}`)
+	})
+	th.Mux.HandleFunc("/unauthorized", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"error": {"message": "The request you have made requires authentication.", "code": 401, "title": "Unauthorized"}}`)
+	})
+	th.Mux.HandleFunc("/opaque", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `Authentication required`)
+	})
+
+	p := &ProviderClient{TokenID: "stale"}
+
+	_, err := p.Request("GET", th.Endpoint()+"expired", RequestOpts{})
+	th.CheckEquals(t, true, IsTokenExpired(err))
+
+	_, err = p.Request("GET", th.Endpoint()+"unauthorized", RequestOpts{})
+	th.CheckEquals(t, false, IsTokenExpired(err))
+	if err, ok := err.(*UnexpectedResponseCodeError); !ok || err.Actual != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 UnexpectedResponseCodeError, but got %#v", err)
+	}
+
+	_, err = p.Request("GET", th.Endpoint()+"opaque", RequestOpts{})
+	th.CheckEquals(t, false, IsTokenExpired(err))
+
+	th.CheckEquals(t, false, IsTokenExpired(ErrEndpointNotFound))
+}
+
+func TestFault(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/fault", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `{"error": {"message": "You are not authorized to perform the requested action.", "code": 403, "title": "Forbidden"}}`)
+	})
+	th.Mux.HandleFunc("/opaque", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, `Access denied`)
+	})
+
+	p := &ProviderClient{TokenID: "1234"}
+
+	_, err := p.Request("GET", th.Endpoint()+"fault", RequestOpts{})
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expected a *Fault, but got %#v", err)
+	}
+	th.CheckDeepEquals(t, &Fault{
+		Code:    http.StatusForbidden,
+		Title:   "Forbidden",
+		Message: "You are not authorized to perform the requested action.",
+	}, fault)
+	th.CheckEquals(t, "403 Forbidden: You are not authorized to perform the requested action.", fault.Error())
+
+	_, err = p.Request("GET", th.Endpoint()+"opaque", RequestOpts{})
+	th.CheckEquals(t, false, errors.As(err, &fault))
+	respErr, ok := err.(*UnexpectedResponseCodeError)
+	if !ok {
+		t.Fatalf("Expected an *UnexpectedResponseCodeError, but got %#v", err)
+	}
+	th.CheckEquals(t, "Access denied", string(respErr.Body))
+	if respErr.Fault != nil {
+		t.Errorf("Expected no Fault for an opaque body, but got %#v", respErr.Fault)
+	}
+}
+
+func TestSetAuthentication(t *testing.T) {
+	client := &ProviderClient{}
+	expiresAt := time.Date(2014, 1, 31, 15, 30, 58, 0, time.UTC)
+	catalog := []string{"catalog"}
+
+	client.SetAuthentication("aaaa", expiresAt, catalog)
+	th.CheckEquals(t, "aaaa", client.Token())
+	th.CheckEquals(t, expiresAt, client.TokenExpiresAt())
+	th.CheckDeepEquals(t, catalog, client.ServiceCatalog())
+
+	client.SetToken("bbbb")
+	th.CheckEquals(t, "bbbb", client.Token())
+	th.CheckEquals(t, true, client.TokenExpiresAt().IsZero())
+	th.CheckDeepEquals(t, catalog, client.ServiceCatalog())
+}
+
+func TestCatalogTTL(t *testing.T) {
+	client := &ProviderClient{}
+	expiresAt := time.Date(2014, 1, 31, 15, 30, 58, 0, time.UTC)
+	catalog := []string{"catalog"}
+
+	client.SetAuthentication("aaaa", expiresAt, catalog)
+	th.CheckEquals(t, false, client.CatalogFresh())
+
+	client.CatalogTTL = time.Hour
+	th.CheckEquals(t, true, client.CatalogFresh())
+
+	client.RenewToken("bbbb", expiresAt.Add(time.Hour))
+	th.CheckEquals(t, "bbbb", client.Token())
+	th.CheckEquals(t, true, expiresAt.Add(time.Hour).Equal(client.TokenExpiresAt()))
+	th.CheckDeepEquals(t, catalog, client.ServiceCatalog())
+	th.CheckEquals(t, true, client.CatalogFresh())
+
+	th.CheckEquals(t, ErrNoReauthFunc, client.RefreshCatalog())
+
+	reauths := 0
+	client.ReauthFunc = func() error {
+		reauths++
+		th.CheckEquals(t, false, client.CatalogFresh())
+		client.SetAuthentication("cccc", expiresAt, []string{"new catalog"})
+		return nil
+	}
+	th.AssertNoErr(t, client.RefreshCatalog())
+	th.CheckEquals(t, 1, reauths)
+	th.CheckDeepEquals(t, []string{"new catalog"}, client.ServiceCatalog())
+	th.CheckEquals(t, true, client.CatalogFresh())
+}
+
+func TestRequestID(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Compute-Request-Id", "req-compute")
+		w.WriteHeader(http.StatusOK)
+	})
+	th.Mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Openstack-Request-Id", "req-failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	p := &ProviderClient{}
+
+	resp, err := p.Request("GET", th.Endpoint()+"ok", RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "req-compute", Result{Header: resp.Header}.RequestID())
+
+	_, err = p.Request("GET", th.Endpoint()+"fail", RequestOpts{})
+	ue, ok := err.(*UnexpectedResponseCodeError)
+	if !ok {
+		t.Fatalf("Expected an UnexpectedResponseCodeError, but got %#v", err)
+	}
+	th.CheckEquals(t, "req-failed", ue.RequestID)
+	th.CheckEquals(t, "req-failed", Result{Err: err}.RequestID())
+	if !strings.Contains(err.Error(), "(request ID req-failed)") {
+		t.Errorf("Expected the error message to include the request ID, but got %q", err.Error())
+	}
+
+	th.CheckEquals(t, "", Result{}.RequestID())
+}
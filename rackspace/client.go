@@ -5,6 +5,7 @@ import (
 
 	"github.com/rackspace/gophercloud"
 	os "github.com/rackspace/gophercloud/openstack"
+	ostokens2 "github.com/rackspace/gophercloud/openstack/identity/v2/tokens"
 	"github.com/rackspace/gophercloud/openstack/utils"
 	tokens2 "github.com/rackspace/gophercloud/rackspace/identity/v2/tokens"
 )
@@ -66,7 +67,7 @@ func Authenticate(client *gophercloud.ProviderClient, options gophercloud.AuthOp
 
 	switch chosen.ID {
 	case v20:
-		return v2auth(client, endpoint, options)
+		return v2auth(client, endpoint, options, false)
 	default:
 		// The switch statement must be out of date from the versions list.
 		return fmt.Errorf("Unrecognized identity version: %s", chosen.ID)
@@ -75,10 +76,13 @@ func Authenticate(client *gophercloud.ProviderClient, options gophercloud.AuthOp
 
 // AuthenticateV2 explicitly authenticates with v2 of the identity service.
 func AuthenticateV2(client *gophercloud.ProviderClient, options gophercloud.AuthOptions) error {
-	return v2auth(client, "", options)
+	return v2auth(client, "", options, false)
 }
 
-func v2auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions) error {
+// v2auth authenticates against the v2 identity service. When re-authenticating with the ReauthFunc it
+// installs, a service catalog that's still fresh according to the client's CatalogTTL is kept, and
+// the one in the response isn't parsed.
+func v2auth(client *gophercloud.ProviderClient, endpoint string, options gophercloud.AuthOptions, reauth bool) error {
 	v2Client := NewIdentityV2(client)
 	if endpoint != "" {
 		v2Client.Endpoint = endpoint
@@ -91,17 +95,25 @@ func v2auth(client *gophercloud.ProviderClient, endpoint string, options gopherc
 		return err
 	}
 
-	catalog, err := result.ExtractServiceCatalog()
-	if err != nil {
-		return err
+	catalog, keep := client.ServiceCatalog().(*ostokens2.ServiceCatalog)
+	keep = keep && reauth && client.CatalogFresh()
+	if !keep {
+		catalog, err = result.ExtractServiceCatalog()
+		if err != nil {
+			return err
+		}
 	}
 
-	if options.AllowReauth {
+	if options.AllowReauth && !reauth {
 		client.ReauthFunc = func() error {
-			return AuthenticateV2(client, options)
+			return v2auth(client, "", options, true)
 		}
 	}
-	client.TokenID = token.ID
+	if keep {
+		client.RenewToken(token.ID, token.ExpiresAt)
+	} else {
+		client.SetAuthentication(token.ID, token.ExpiresAt, catalog)
+	}
 	client.EndpointLocator = func(opts gophercloud.EndpointOpts) (string, error) {
 		return os.V2EndpointURL(catalog, opts)
 	}
@@ -122,7 +134,7 @@ func NewIdentityV2(client *gophercloud.ProviderClient) *gophercloud.ServiceClien
 // NewComputeV2 creates a ServiceClient that may be used to access the v2 compute service.
 func NewComputeV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("compute")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
@@ -130,17 +142,18 @@ func NewComputeV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpt
 	return &gophercloud.ServiceClient{
 		ProviderClient: client,
 		Endpoint:       url,
+		Region:         eo.Region,
 	}, nil
 }
 
 // NewObjectCDNV1 creates a ServiceClient that may be used with the Rackspace v1 CDN.
 func NewObjectCDNV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("rax:object-cdn")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewObjectStorageV1 creates a ServiceClient that may be used with the Rackspace v1 object storage package.
@@ -152,63 +165,63 @@ func NewObjectStorageV1(client *gophercloud.ProviderClient, eo gophercloud.Endpo
 // Rackspace Cloud Block Storage v1 API.
 func NewBlockStorageV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("volume")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
 
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewLBV1 creates a ServiceClient that can be used to access the Rackspace
 // Cloud Load Balancer v1 API.
 func NewLBV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("rax:load-balancer")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewNetworkV2 creates a ServiceClient that can be used to access the Rackspace
 // Networking v2 API.
 func NewNetworkV2(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("network")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewCDNV1 creates a ServiceClient that may be used to access the Rackspace v1
 // CDN service.
 func NewCDNV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("rax:cdn")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewOrchestrationV1 creates a ServiceClient that may be used to access the v1 orchestration service.
 func NewOrchestrationV1(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("orchestration")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
 
 // NewRackConnectV3 creates a ServiceClient that may be used to access the v3 RackConnect service.
 func NewRackConnectV3(client *gophercloud.ProviderClient, eo gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error) {
 	eo.ApplyDefaults("rax:rackconnect")
-	url, err := client.EndpointLocator(eo)
+	url, err := client.LocateEndpoint(eo)
 	if err != nil {
 		return nil, err
 	}
-	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url}, nil
+	return &gophercloud.ServiceClient{ProviderClient: client, Endpoint: url, Region: eo.Region}, nil
 }
@@ -8,7 +8,10 @@ import (
 )
 
 var (
-	// ErrPasswordProvided is returned if both a password and an API key are provided to Create.
+	// ErrPasswordProvided is unused: when both a password and an API key are provided to Create,
+	// the API key takes precedence and the password is ignored.
+	//
+	// Deprecated: nothing returns this error.
 	ErrPasswordProvided = errors.New("Please provide either a password or an API key.")
 )
 
@@ -23,8 +26,9 @@ func WrapOptions(original gophercloud.AuthOptions) AuthOptions {
 	return AuthOptions{AuthOptions: os.WrapOptions(original)}
 }
 
-// ToTokenCreateMap serializes an AuthOptions into a request body. If an API key is provided, it
-// will be used, otherwise
+// ToTokenCreateMap serializes an AuthOptions into a request body. If an API key is provided, it's
+// sent as RAX-KSKEY:apiKeyCredentials along with the Username, and takes precedence over a Password,
+// which is ignored. Otherwise, the body is built as the OpenStack AuthOptions would build it.
 func (auth AuthOptions) ToTokenCreateMap() (map[string]interface{}, error) {
 	if auth.APIKey == "" {
 		return auth.AuthOptions.ToTokenCreateMap()
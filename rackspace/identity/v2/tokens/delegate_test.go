@@ -34,3 +34,24 @@ func TestCreateTokenWithAPIKey(t *testing.T) {
     }
   `))
 }
+
+func TestCreateTokenPrefersAPIKey(t *testing.T) {
+	options := gophercloud.AuthOptions{
+		Username:   "me",
+		Password:   "swordfish",
+		APIKey:     "1234567890abcdef",
+		TenantName: "demo",
+	}
+
+	os.IsSuccessful(t, tokenPost(t, options, `
+    {
+      "auth": {
+        "RAX-KSKEY:apiKeyCredentials": {
+          "username": "me",
+          "apiKey": "1234567890abcdef"
+        },
+        "tenantName": "demo"
+      }
+    }
+  `))
+}
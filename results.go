@@ -47,6 +47,32 @@ func (r Result) PrettyPrintJSON() string {
 	return string(pretty)
 }
 
+// RequestIDHeaders are the response headers in which OpenStack services report the ID they assigned
+// to a request, in order of preference. Quote it when asking a cloud operator about a failure.
+var RequestIDHeaders = []string{"X-OpenStack-Request-Id", "X-Compute-Request-Id"}
+
+// RequestID returns the request ID reported in a set of response headers, or "" if there is none.
+func RequestID(header http.Header) string {
+	for _, name := range RequestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// RequestID returns the ID the service assigned to the request that produced this Result, or "" if
+// it didn't report one. It's available whether or not the request succeeded.
+func (r Result) RequestID() string {
+	if id := RequestID(r.Header); id != "" {
+		return id
+	}
+	if err, ok := r.Err.(*UnexpectedResponseCodeError); ok {
+		return err.RequestID
+	}
+	return ""
+}
+
 // ErrResult is an internal type to be used by individual resource packages, but
 // its methods will be available on a wide variety of user-facing embedding
 // types.
@@ -113,6 +139,10 @@ func DecodeHeader(from, to interface{}) error {
 // RFC3339Milli describes a common time format used by some API responses.
 const RFC3339Milli = "2006-01-02T15:04:05.999999Z"
 
+// RFC3339NoZ describes a time format used by some API responses that omit the
+// time zone designator altogether. Such timestamps are interpreted as UTC.
+const RFC3339NoZ = "2006-01-02T15:04:05"
+
 // Time format used in cloud orchestration
 const STACK_TIME_FMT = "2006-01-02T15:04:05"
 
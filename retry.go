@@ -0,0 +1,212 @@
+package gophercloud
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts, including the first, made by a RetryTransport
+	// that doesn't specify MaxAttempts.
+	DefaultRetryAttempts = 3
+
+	// DefaultRetryBaseDelay is the delay before the first retry made by a RetryTransport that doesn't
+	// specify BaseDelay. It doubles with each subsequent retry.
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+
+	// DefaultRetryMaxDelay is the longest a RetryTransport that doesn't specify MaxDelay will wait
+	// between attempts.
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// DefaultRetryStatusCodes are the HTTP response codes retried by a RetryTransport that doesn't
+// specify RetryStatusCodes. They're the ones an overloaded control plane tends to produce.
+var DefaultRetryStatusCodes = []int{500, 502, 503, 504}
+
+// RetryTransport is an http.RoundTripper that retries requests which fail with a transient error,
+// waiting an exponentially increasing, jittered delay between attempts. If a response carries a
+// Retry-After header, it's honored instead, up to MaxDelay.
+//
+// Only idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE and TRACE) are retried unless
+// RetryNonIdempotent is set. Requests with a body are only retried if it can be rewound through the
+// request's GetBody. ProviderClient provides one for every request with a body: a JSONBody is always
+// rewindable, and a RawBody is rewound by seeking back to where it started.
+//
+// To use it, wrap the ProviderClient's existing transport:
+//
+//	provider.HTTPClient.Transport = &gophercloud.RetryTransport{
+//		Transport: provider.HTTPClient.Transport,
+//	}
+//
+// The zero value retries with the defaults declared above and uses http.DefaultTransport.
+type RetryTransport struct {
+	// Transport issues each individual attempt. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// MaxAttempts is the total number of attempts to make, including the first one.
+	MaxAttempts int
+
+	// RetryStatusCodes lists the HTTP response codes that should be retried. Connection errors are
+	// always retried.
+	RetryStatusCodes []int
+
+	// BaseDelay is the delay before the first retry. It doubles with each subsequent retry, and a
+	// random jitter of up to the same amount again is added.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between any two attempts, including one requested by Retry-After.
+	MaxDelay time.Duration
+
+	// RetryNonIdempotent allows POST and PATCH requests to be retried as well. Enable it only if
+	// replaying such a request can't create duplicate resources.
+	RetryNonIdempotent bool
+
+	// sleep waits for the given duration, or until the request is cancelled. Tests replace it.
+	sleep func(req *http.Request, d time.Duration) error
+}
+
+// RoundTrip issues the request, retrying it according to the transport's policy. The response to
+// the final attempt is returned as-is.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	attempts := t.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	if !t.canRetry(req) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Rewind the body on a shallow copy, since a RoundTripper mustn't modify the request.
+		current := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			copied := *req
+			copied.Body = body
+			current = &copied
+		}
+
+		resp, err := transport.RoundTrip(current)
+		if attempt >= attempts || !t.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.delay(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// canRetry reports whether the request may safely be issued more than once.
+func (t *RetryTransport) canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE", "TRACE":
+		return true
+	}
+	return t.RetryNonIdempotent
+}
+
+// shouldRetry reports whether an attempt failed in a way that's worth retrying.
+func (t *RetryTransport) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	codes := t.RetryStatusCodes
+	if codes == nil {
+		codes = DefaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay computes how long to wait after the given attempt.
+func (t *RetryTransport) delay(attempt int, resp *http.Response) time.Duration {
+	base, max := t.BaseDelay, t.MaxDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = DefaultRetryMaxDelay
+	}
+
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if d > max {
+				return max
+			}
+			return d
+		}
+	}
+
+	backoff := base << uint(attempt-1)
+	if backoff <= 0 || backoff > max {
+		backoff = max
+	}
+	d := backoff + time.Duration(rand.Int63n(int64(backoff)+1))
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a number of seconds or an
+// HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		d := when.Sub(time.Now())
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d to elapse, returning early with an error if the request is cancelled.
+func sleepContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
@@ -0,0 +1,175 @@
+package gophercloud
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+// recordSleeps returns a sleep function that records the requested delays instead of waiting.
+func recordSleeps(delays *[]time.Duration) func(*http.Request, time.Duration) error {
+	return func(req *http.Request, d time.Duration) error {
+		*delays = append(*delays, d)
+		return nil
+	}
+}
+
+func TestRetryTransportRetriesTransientErrors(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var bodies []string
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	var delays []time.Duration
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{BaseDelay: time.Second, MaxDelay: time.Minute, sleep: recordSleeps(&delays)}
+
+	_, err := p.Request("PUT", th.Endpoint()+"route", RequestOpts{JSONBody: map[string]string{"a": "b"}})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{`{"a":"b"}`, `{"a":"b"}`, `{"a":"b"}`}, bodies)
+
+	th.CheckEquals(t, 2, len(delays))
+	if delays[0] < time.Second || delays[0] > 2*time.Second {
+		t.Errorf("Expected the first delay to be between 1s and 2s, but got %s", delays[0])
+	}
+	if delays[1] < 2*time.Second || delays[1] > 4*time.Second {
+		t.Errorf("Expected the second delay to be between 2s and 4s, but got %s", delays[1])
+	}
+}
+
+// seekerOnly hides the concrete type of a reader, so that net/http can't rewind it by itself.
+type seekerOnly struct {
+	io.ReadSeeker
+}
+
+func TestRetryTransportRewindsRawBody(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var bodies []string
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	var delays []time.Duration
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{sleep: recordSleeps(&delays)}
+
+	raw := strings.NewReader("skipped:payload")
+	raw.Seek(int64(len("skipped:")), io.SeekStart)
+	_, err := p.Request("PUT", th.Endpoint()+"route", RequestOpts{RawBody: seekerOnly{raw}})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"payload", "payload"}, bodies)
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	attempts := 0
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	var delays []time.Duration
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{MaxAttempts: 4, sleep: recordSleeps(&delays)}
+
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	if err, ok := err.(*UnexpectedResponseCodeError); !ok || err.Actual != http.StatusInternalServerError {
+		t.Fatalf("Expected a 500 UnexpectedResponseCodeError, but got %#v", err)
+	}
+	th.CheckEquals(t, 4, attempts)
+	th.CheckEquals(t, 3, len(delays))
+}
+
+func TestRetryTransportSkipsNonIdempotentRequests(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	attempts := 0
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	var delays []time.Duration
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{sleep: recordSleeps(&delays)}
+
+	_, err := p.Request("POST", th.Endpoint()+"route", RequestOpts{JSONBody: map[string]string{}})
+	if err == nil {
+		t.Fatalf("Expected the 503 to be returned")
+	}
+	th.CheckEquals(t, 1, attempts)
+
+	attempts = 0
+	p.HTTPClient.Transport = &RetryTransport{RetryNonIdempotent: true, sleep: recordSleeps(&delays)}
+	p.Request("POST", th.Endpoint()+"route", RequestOpts{JSONBody: map[string]string{}})
+	th.CheckEquals(t, DefaultRetryAttempts, attempts)
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	attempts := 0
+	th.Mux.HandleFunc("/route", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if attempts == 2 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var delays []time.Duration
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{MaxDelay: time.Minute, sleep: recordSleeps(&delays)}
+
+	_, err := p.Request("GET", th.Endpoint()+"route", RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []time.Duration{7 * time.Second, time.Minute}, delays)
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := retryAfter("120")
+	th.CheckEquals(t, true, ok)
+	th.CheckEquals(t, 2*time.Minute, d)
+
+	d, ok = retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	th.CheckEquals(t, true, ok)
+	th.CheckEquals(t, time.Duration(0), d)
+
+	_, ok = retryAfter("")
+	th.CheckEquals(t, false, ok)
+	_, ok = retryAfter("soon")
+	th.CheckEquals(t, false, ok)
+}
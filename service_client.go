@@ -16,6 +16,10 @@ type ServiceClient struct {
 	// the API version and, like Endpoint, MUST end with a / if set. If not set, the Endpoint is used
 	// as-is, instead.
 	ResourceBase string
+
+	// Region is the region whose endpoint this client was resolved for, if one was specified. It's
+	// used as the default region by LocateEndpoint.
+	Region string
 }
 
 // ResourceBaseURL returns the base URL of any resources used by this service. It MUST end with a /.
@@ -30,3 +34,29 @@ func (client *ServiceClient) ResourceBaseURL() string {
 func (client *ServiceClient) ServiceURL(parts ...string) string {
 	return client.ResourceBaseURL() + strings.Join(parts, "/")
 }
+
+// LocateEndpoint discovers the URL of another service's endpoint, as ProviderClient.LocateEndpoint
+// does. If the provided EndpointOpts don't specify a Region, the client's own Region is used, so that
+// clients derived from this one target the same region unless told otherwise.
+func (client *ServiceClient) LocateEndpoint(eo EndpointOpts) (string, error) {
+	if eo.Region == "" {
+		eo.Region = client.Region
+	}
+	return client.ProviderClient.LocateEndpoint(eo)
+}
+
+// ForEndpoint returns a copy of the client that addresses the service at another URL, such as the
+// same service in another region. The copy shares the ProviderClient, and so its token and
+// transport, without re-authenticating; both clients may be used concurrently. A ResourceBase that
+// extended the old Endpoint is carried over to the new one. Since the URL's region isn't known, the
+// copy's Region is cleared; set it if LocateEndpoint should default to that region.
+func (client *ServiceClient) ForEndpoint(url string) *ServiceClient {
+	copied := *client
+	copied.Endpoint = NormalizeURL(url)
+	copied.ResourceBase = ""
+	copied.Region = ""
+	if client.ResourceBase != "" && strings.HasPrefix(client.ResourceBase, client.Endpoint) {
+		copied.ResourceBase = copied.Endpoint + strings.TrimPrefix(client.ResourceBase, client.Endpoint)
+	}
+	return &copied
+}
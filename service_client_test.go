@@ -12,3 +12,63 @@ func TestServiceURL(t *testing.T) {
 	actual := c.ServiceURL("more", "parts", "here")
 	th.CheckEquals(t, expected, actual)
 }
+
+func TestLocateEndpointDefaultsRegion(t *testing.T) {
+	var regions []string
+	p := &ProviderClient{
+		EndpointLocator: func(eo EndpointOpts) (string, error) {
+			regions = append(regions, eo.Region)
+			return "http://" + eo.Region + "/", nil
+		},
+	}
+	c := &ServiceClient{ProviderClient: p, Region: "RegionOne"}
+
+	url, err := c.LocateEndpoint(EndpointOpts{Type: "network"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://RegionOne/", url)
+
+	url, err = c.LocateEndpoint(EndpointOpts{Type: "network", Region: "RegionTwo"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://RegionTwo/", url)
+
+	th.CheckDeepEquals(t, []string{"RegionOne", "RegionTwo"}, regions)
+}
+
+func TestLocateEndpointOverride(t *testing.T) {
+	p := &ProviderClient{
+		EndpointLocator: func(eo EndpointOpts) (string, error) {
+			return "", ErrEndpointNotFound
+		},
+		EndpointOverrides: map[string]string{"compute": "http://localhost:8774/v2/tenant"},
+	}
+	c := &ServiceClient{ProviderClient: p}
+
+	url, err := c.LocateEndpoint(EndpointOpts{Type: "compute", Region: "anywhere"})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "http://localhost:8774/v2/tenant/", url)
+
+	_, err = c.LocateEndpoint(EndpointOpts{Type: "network"})
+	th.CheckEquals(t, ErrEndpointNotFound, err)
+}
+
+func TestForEndpoint(t *testing.T) {
+	p := &ProviderClient{TokenID: "1234"}
+	c := &ServiceClient{
+		ProviderClient: p,
+		Endpoint:       "http://north.example.com/",
+		ResourceBase:   "http://north.example.com/v2/",
+		Region:         "North",
+	}
+
+	south := c.ForEndpoint("http://south.example.com")
+	th.CheckEquals(t, "http://south.example.com/", south.Endpoint)
+	th.CheckEquals(t, "http://south.example.com/v2/servers", south.ServiceURL("servers"))
+	th.CheckEquals(t, "", south.Region)
+	if south.ProviderClient != p {
+		t.Errorf("Expected the copy to share the ProviderClient")
+	}
+
+	// The original is unchanged.
+	th.CheckEquals(t, "http://north.example.com/v2/servers", c.ServiceURL("servers"))
+	th.CheckEquals(t, "North", c.Region)
+}
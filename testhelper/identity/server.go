@@ -0,0 +1,193 @@
+/*
+Package identity provides a fake identity (Keystone v2.0) service for testing code that
+authenticates. It issues tokens with a configurable ID, expiry and service catalog, and can be told
+to return arbitrary responses in order to exercise error handling.
+
+	server := identity.NewServer()
+	defer server.Close()
+
+	server.SetCatalog(identity.Service{
+		Type:      "compute",
+		Endpoints: []identity.Endpoint{{Region: "RegionOne", PublicURL: "http://compute.example.com/v2/"}},
+	})
+
+	provider, err := openstack.AuthenticatedClient(gophercloud.AuthOptions{
+		IdentityEndpoint: server.Endpoint(),
+		Username:         "me",
+		Password:         "swordfish",
+	})
+*/
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+// Endpoint is one regional deployment of a Service in the catalog.
+type Endpoint struct {
+	Region      string `json:"region,omitempty"`
+	PublicURL   string `json:"publicURL,omitempty"`
+	InternalURL string `json:"internalURL,omitempty"`
+	AdminURL    string `json:"adminURL,omitempty"`
+}
+
+// Service is an entry in the service catalog.
+type Service struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name,omitempty"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+// Token configures the tokens issued by a Server.
+type Token struct {
+	// ID is the ID of every token issued. If empty, each token gets a distinct ID of the form
+	// "token-1", "token-2" and so on, so that re-authentication can be observed.
+	ID string
+
+	// ExpiresAt is the expiry of every token issued. If zero, tokens expire an hour after they're
+	// issued.
+	ExpiresAt time.Time
+
+	// TenantID and TenantName describe the tenant the token is scoped to. If both are empty, the
+	// tenant requested by the client is echoed back.
+	TenantID   string
+	TenantName string
+}
+
+// Server is a fake identity service. Its zero value isn't usable; create one with NewServer.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	token    Token
+	catalog  []Service
+	code     int
+	body     string
+	requests int
+}
+
+// NewServer starts a fake identity service that issues tokens with an empty service catalog. Close
+// it when the test is done.
+func NewServer() *Server {
+	s := &Server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleVersions)
+	mux.HandleFunc("/v2.0/tokens", s.handleTokens)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Endpoint returns the identity endpoint to place in AuthOptions.
+func (s *Server) Endpoint() string {
+	return s.URL + "/v2.0/"
+}
+
+// SetToken configures the tokens issued from now on.
+func (s *Server) SetToken(token Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetCatalog replaces the service catalog returned alongside each token.
+func (s *Server) SetCatalog(services ...Service) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog = services
+}
+
+// SetResponse makes every subsequent token request respond with the given status code and body
+// verbatim, such as a malformed timestamp or an ambiguous catalog. Pass a code of 0 to go back to
+// issuing tokens normally.
+func (s *Server) SetResponse(code int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.code, s.body = code, body
+}
+
+// Requests returns the number of token requests the server has received.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// handleVersions advertises the single identity version the server supports.
+func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultipleChoices)
+	fmt.Fprintf(w, `{"versions": {"values": [{"id": "v2.0", "status": "stable", "links": [{"href": %q, "rel": "self"}]}]}}`, s.Endpoint())
+}
+
+// handleTokens issues a token, or the configured canned response.
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Auth struct {
+			TenantID   string `json:"tenantId"`
+			TenantName string `json:"tenantName"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	w.Header().Set("Content-Type", "application/json")
+	if s.code != 0 {
+		w.WriteHeader(s.code)
+		fmt.Fprint(w, s.body)
+		return
+	}
+
+	id := s.token.ID
+	if id == "" {
+		id = fmt.Sprintf("token-%d", s.requests)
+	}
+	expires := s.token.ExpiresAt
+	if expires.IsZero() {
+		expires = time.Now().Add(time.Hour)
+	}
+	tenantID, tenantName := s.token.TenantID, s.token.TenantName
+	if tenantID == "" && tenantName == "" {
+		tenantID, tenantName = request.Auth.TenantID, request.Auth.TenantName
+	}
+	catalog := s.catalog
+	if catalog == nil {
+		catalog = []Service{}
+	}
+
+	token := map[string]interface{}{
+		"id":      id,
+		"expires": expires.UTC().Format(time.RFC3339),
+	}
+	if tenantID != "" || tenantName != "" {
+		token["tenant"] = map[string]interface{}{"id": tenantID, "name": tenantName, "enabled": true}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access": map[string]interface{}{
+			"token":          token,
+			"user":           map[string]interface{}{"id": "user", "name": "user", "roles": []interface{}{}},
+			"serviceCatalog": catalog,
+		},
+	})
+}
@@ -0,0 +1,21 @@
+package gophercloud
+
+// TokenScope indicates what a token grants access to, according to the scope the identity service
+// reported when it was issued.
+type TokenScope string
+
+const (
+	// TokenScopeProject indicates that a token is scoped to a project, or a tenant in the terms of
+	// Identity v2.
+	TokenScopeProject TokenScope = "project"
+
+	// TokenScopeDomain indicates that a token is scoped to a domain.
+	TokenScopeDomain TokenScope = "domain"
+
+	// TokenScopeSystem indicates that a token is scoped to the deployment as a whole.
+	TokenScopeSystem TokenScope = "system"
+
+	// TokenScopeUnscoped indicates that a token carries no scope, so it can only be used to discover
+	// the projects the user can access or be exchanged for a scoped one.
+	TokenScopeUnscoped TokenScope = "unscoped"
+)
@@ -0,0 +1,249 @@
+package gophercloud
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrCustomTransport is returned when configuring the transport of a ProviderClient whose
+// HTTPClient has been given a Transport other than an *http.Transport. Configure that transport
+// directly instead.
+var ErrCustomTransport = errors.New("The HTTPClient's Transport isn't an *http.Transport, so it can't be configured.")
+
+// transport returns a copy of the *http.Transport used by the client's HTTPClient, starting from
+// http.DefaultTransport if none has been set. Callers modify the copy and then assign it to
+// client.HTTPClient.Transport, so that transports shared with other clients are never altered.
+func (client *ProviderClient) transport() (*http.Transport, error) {
+	current := client.HTTPClient.Transport
+	if current == nil {
+		current = http.DefaultTransport
+	}
+
+	switch t := current.(type) {
+	case *http.Transport:
+		return t.Clone(), nil
+	default:
+		return nil, ErrCustomTransport
+	}
+}
+
+// SetHTTPClient makes the client send every request, including authentication requests, with the
+// configuration of httpClient: its Transport, Timeout, CheckRedirect and Jar. Use it to adopt an
+// http.Client that's already instrumented or routed through a proxy. The Transport is shared rather
+// than copied, so middleware it contains sees the client's traffic.
+//
+// Passing nil restores the configuration the providers' NewClient functions start with: a copy of
+// http.DefaultTransport limited by DefaultConnectionPool, and no Timeout. Every setting applied
+// earlier, whether by SetHTTPClient, SetTimeouts, SetProxy or SetTLSConfig, is discarded.
+//
+// To have the initial authentication request use httpClient, create the client with NewClient, call
+// SetHTTPClient, and then authenticate, rather than calling AuthenticatedClient. Settings applied
+// afterwards, such as SetTLSConfig, alter a copy of its Transport and leave httpClient as it is.
+func (client *ProviderClient) SetHTTPClient(httpClient *http.Client) {
+	if httpClient == nil {
+		client.HTTPClient = http.Client{}
+		// This only fails if http.DefaultTransport has been replaced, in which case it's used as-is.
+		client.SetConnectionPool(DefaultConnectionPool)
+		return
+	}
+	client.HTTPClient = *httpClient
+}
+
+// SetProxy routes every request made by the client, including authentication requests, through
+// the HTTP, HTTPS or SOCKS5 proxy at proxyURL. Hosts listed in the NO_PROXY environment variable
+// are contacted directly, so that endpoints on an internal network can bypass the proxy.
+func (client *ProviderClient) SetProxy(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+
+	return client.SetProxyFunc(func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return u, nil
+	})
+}
+
+// SetProxyFunc routes requests made by the client through the proxy returned by proxy, which has
+// the same semantics as the Proxy field of an http.Transport: a nil URL means no proxy is used.
+func (client *ProviderClient) SetProxyFunc(proxy func(*http.Request) (*url.URL, error)) error {
+	t, err := client.transport()
+	if err != nil {
+		return err
+	}
+	t.Proxy = proxy
+	client.HTTPClient.Transport = t
+	return nil
+}
+
+// SetTLSConfig makes every request made by the client, including authentication requests, use a
+// copy of config. Use it to trust an internal certificate authority by populating RootCAs, or to
+// present a client certificate. Certificate verification can only be disabled by explicitly setting
+// InsecureSkipVerify, which should never be done outside of development clusters.
+//
+// To have the initial authentication request honor the config, create the client with NewClient,
+// call SetTLSConfig, and then authenticate, rather than calling AuthenticatedClient.
+func (client *ProviderClient) SetTLSConfig(config *tls.Config) error {
+	t, err := client.transport()
+	if err != nil {
+		return err
+	}
+	t.TLSClientConfig = config.Clone()
+	client.HTTPClient.Transport = t
+	return nil
+}
+
+// TransportTimeouts bound the individual phases of a request, as opposed to the overall Timeout of
+// an http.Client, which would also cut off a large download that's still making progress. A zero
+// value leaves the corresponding limit of the client's transport as it is.
+type TransportTimeouts struct {
+	// Dial limits how long establishing a TCP connection may take.
+	Dial time.Duration
+
+	// TLSHandshake limits how long negotiating TLS on a new connection may take.
+	TLSHandshake time.Duration
+
+	// ResponseHeader limits how long to wait for the response headers once the request has been
+	// written. Reading the response body isn't affected, so streaming downloads may take as long as
+	// they need to.
+	ResponseHeader time.Duration
+}
+
+// DefaultTransportTimeouts catch a stalled control plane without interrupting requests that are
+// merely slow.
+var DefaultTransportTimeouts = TransportTimeouts{
+	Dial:           30 * time.Second,
+	TLSHandshake:   10 * time.Second,
+	ResponseHeader: 60 * time.Second,
+}
+
+// SetTimeouts applies timeouts to the individual phases of every request made by the client. Pass
+// DefaultTransportTimeouts for sensible limits.
+func (client *ProviderClient) SetTimeouts(timeouts TransportTimeouts) error {
+	t, err := client.transport()
+	if err != nil {
+		return err
+	}
+	if timeouts.Dial > 0 {
+		t.DialContext = (&net.Dialer{
+			Timeout:   timeouts.Dial,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if timeouts.TLSHandshake > 0 {
+		t.TLSHandshakeTimeout = timeouts.TLSHandshake
+	}
+	if timeouts.ResponseHeader > 0 {
+		t.ResponseHeaderTimeout = timeouts.ResponseHeader
+	}
+	client.HTTPClient.Transport = t
+	return nil
+}
+
+// ConnectionPool limits the connections a client's transport keeps to the services it talks to. A
+// zero value leaves the corresponding limit of the client's transport as it is.
+type ConnectionPool struct {
+	// MaxIdleConns limits the idle keep-alive connections kept across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost limits the idle keep-alive connections kept to each host. Go's own default
+	// of 2 suits one-shot tools, but forces concurrent workloads to keep opening new connections.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the connections to each host, whether idle or in use. Requests beyond
+	// the limit wait for a connection to become available.
+	MaxConnsPerHost int
+}
+
+// DefaultConnectionPool keeps enough idle connections to the handful of control-plane endpoints a
+// long-running server talks to that concurrent requests can reuse them. It doesn't cap the number
+// of connections per host.
+var DefaultConnectionPool = ConnectionPool{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 32,
+}
+
+// SetConnectionPool applies connection limits to the client's transport. The openstack and rackspace
+// providers' NewClient functions apply DefaultConnectionPool, which suits server workloads.
+func (client *ProviderClient) SetConnectionPool(pool ConnectionPool) error {
+	t, err := client.transport()
+	if err != nil {
+		return err
+	}
+	if pool.MaxIdleConns > 0 {
+		t.MaxIdleConns = pool.MaxIdleConns
+	}
+	if pool.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	}
+	if pool.MaxConnsPerHost > 0 {
+		t.MaxConnsPerHost = pool.MaxConnsPerHost
+	}
+	client.HTTPClient.Transport = t
+	return nil
+}
+
+// bypassProxy reports whether a request to u should skip the proxy according to noProxy, a
+// comma-separated list of host names, domain suffixes, IP addresses and CIDR ranges in the format
+// of the NO_PROXY environment variable. Entries may carry a port, and "*" matches every host.
+func bypassProxy(u *url.URL, noProxy string) bool {
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		suffix := strings.TrimPrefix(entryHost, "*")
+		if !strings.HasPrefix(suffix, ".") {
+			if host == suffix {
+				return true
+			}
+			suffix = "." + suffix
+		}
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
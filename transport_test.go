@@ -0,0 +1,215 @@
+package gophercloud
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	th "github.com/rackspace/gophercloud/testhelper"
+)
+
+func TestSetProxy(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	// Acting as a forward proxy, the test server receives absolute request URLs.
+	var proxied []string
+	th.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	p := &ProviderClient{}
+	th.AssertNoErr(t, p.SetProxy(th.Endpoint()))
+
+	_, err := p.Request("GET", "http://identity.example.com/v2.0/", RequestOpts{})
+	th.AssertNoErr(t, err)
+	th.CheckDeepEquals(t, []string{"http://identity.example.com/v2.0/"}, proxied)
+}
+
+func TestSetProxyDoesNotAlterSharedTransport(t *testing.T) {
+	shared := &http.Transport{}
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = shared
+
+	th.AssertNoErr(t, p.SetProxy("http://proxy.example.com:3128"))
+	if p.HTTPClient.Transport == shared {
+		t.Errorf("Expected the shared transport to be replaced by a copy")
+	}
+	if shared.Proxy != nil {
+		t.Errorf("Expected the shared transport to be left alone")
+	}
+}
+
+func TestSetProxyCustomTransport(t *testing.T) {
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &RetryTransport{}
+	th.CheckEquals(t, ErrCustomTransport, p.SetProxy("http://proxy.example.com:3128"))
+}
+
+func TestBypassProxy(t *testing.T) {
+	noProxy := "localhost, .internal.example.com, example.org, 10.0.0.0/8, 192.168.1.1, admin.example.net:35357"
+
+	cases := map[string]bool{
+		"http://localhost:5000/":                  true,
+		"http://keystone.internal.example.com/":   true,
+		"http://internal.example.com/":            false,
+		"http://example.org/":                     true,
+		"http://api.example.org/":                 true,
+		"http://notexample.org/":                  false,
+		"http://10.1.2.3:8774/":                   true,
+		"http://11.1.2.3:8774/":                   false,
+		"http://192.168.1.1/":                     true,
+		"https://admin.example.net:35357/v2.0/":   true,
+		"https://admin.example.net:5000/v2.0/":    false,
+		"https://public.example.com/v2.0/tokens/": false,
+	}
+	for raw, expected := range cases {
+		u, err := url.Parse(raw)
+		th.AssertNoErr(t, err)
+		if actual := bypassProxy(u, noProxy); actual != expected {
+			t.Errorf("Expected bypassProxy(%s) to be %t, but was %t", raw, expected, actual)
+		}
+	}
+
+	u, _ := url.Parse("http://anything.example.com/")
+	th.CheckEquals(t, true, bypassProxy(u, "*"))
+	th.CheckEquals(t, false, bypassProxy(u, ""))
+}
+
+func TestSetTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// The server's certificate isn't trusted by default.
+	p := &ProviderClient{}
+	_, err := p.Request("GET", server.URL, RequestOpts{})
+	if err == nil {
+		t.Fatalf("Expected the server's certificate to be rejected")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	config := &tls.Config{RootCAs: pool}
+	th.AssertNoErr(t, p.SetTLSConfig(config))
+
+	_, err = p.Request("GET", server.URL, RequestOpts{})
+	th.AssertNoErr(t, err)
+
+	// The client keeps its own copy of the config.
+	config.RootCAs = nil
+	_, err = p.Request("GET", server.URL, RequestOpts{})
+	th.AssertNoErr(t, err)
+}
+
+func TestSetTimeouts(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/stalled", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	th.Mux.HandleFunc("/streaming", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	p := &ProviderClient{}
+	th.AssertNoErr(t, p.SetTimeouts(TransportTimeouts{ResponseHeader: 20 * time.Millisecond}))
+
+	_, err := p.Request("GET", th.Endpoint()+"stalled", RequestOpts{})
+	if err == nil || !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("Expected the stalled request to time out, but got %v", err)
+	}
+
+	// Once the headers have arrived, a slow body is allowed to finish.
+	resp, err := p.Request("GET", th.Endpoint()+"streaming", RequestOpts{})
+	th.AssertNoErr(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, "done", string(body))
+}
+
+func TestSetTimeoutsKeepsUnsetLimits(t *testing.T) {
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &http.Transport{TLSHandshakeTimeout: time.Minute}
+
+	th.AssertNoErr(t, p.SetTimeouts(TransportTimeouts{ResponseHeader: time.Second}))
+	transport := p.HTTPClient.Transport.(*http.Transport)
+	th.CheckEquals(t, time.Minute, transport.TLSHandshakeTimeout)
+	th.CheckEquals(t, time.Second, transport.ResponseHeaderTimeout)
+}
+
+func TestSetConnectionPool(t *testing.T) {
+	p := &ProviderClient{}
+	p.HTTPClient.Transport = &http.Transport{MaxIdleConns: 10, MaxConnsPerHost: 8}
+
+	th.AssertNoErr(t, p.SetConnectionPool(ConnectionPool{MaxIdleConnsPerHost: 4}))
+	transport := p.HTTPClient.Transport.(*http.Transport)
+	th.CheckEquals(t, 10, transport.MaxIdleConns)
+	th.CheckEquals(t, 4, transport.MaxIdleConnsPerHost)
+	th.CheckEquals(t, 8, transport.MaxConnsPerHost)
+
+	p = &ProviderClient{}
+	th.AssertNoErr(t, p.SetConnectionPool(DefaultConnectionPool))
+	transport = p.HTTPClient.Transport.(*http.Transport)
+	th.CheckEquals(t, 100, transport.MaxIdleConns)
+	th.CheckEquals(t, 32, transport.MaxIdleConnsPerHost)
+	th.CheckEquals(t, 0, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost)
+
+	p.HTTPClient.Transport = http.NewFileTransport(http.Dir("."))
+	th.CheckEquals(t, ErrCustomTransport, p.SetConnectionPool(DefaultConnectionPool))
+}
+
+// countingTransport counts the requests it forwards to http.DefaultTransport.
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport, Timeout: time.Minute}
+
+	client := &ProviderClient{}
+	client.SetHTTPClient(httpClient)
+	th.CheckEquals(t, time.Minute, client.HTTPClient.Timeout)
+
+	_, err := client.Request("GET", th.Endpoint(), RequestOpts{OkCodes: []int{204}})
+	th.AssertNoErr(t, err)
+	th.CheckEquals(t, 1, transport.requests)
+
+	client.SetHTTPClient(nil)
+	th.CheckEquals(t, time.Duration(0), client.HTTPClient.Timeout)
+	pooled, ok := client.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, but got %#v", client.HTTPClient.Transport)
+	}
+	th.CheckEquals(t, DefaultConnectionPool.MaxIdleConnsPerHost, pooled.MaxIdleConnsPerHost)
+	th.CheckEquals(t, true, pooled != http.DefaultTransport)
+}
@@ -2,6 +2,7 @@ package gophercloud
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"path/filepath"
 	"strings"
@@ -36,15 +37,63 @@ func WaitFor(timeout int, predicate func() (bool, error)) error {
 
 // NormalizeURL is an internal function to be used by provider clients.
 //
-// It ensures that each endpoint URL has a closing `/`, as expected by
-// ServiceClient's methods.
+// It ensures that the path of each endpoint URL has a closing `/`, as expected
+// by ServiceClient's methods, and collapses runs of slashes within it, which
+// some service catalogs contain by accident. Any query string or fragment is
+// left untouched. Normalizing an already normalized URL leaves it unchanged.
 func NormalizeURL(url string) string {
-	if !strings.HasSuffix(url, "/") {
-		return url + "/"
+	url = collapseSlashes(url)
+	end := len(url)
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		end = i
+	}
+	if !strings.HasSuffix(url[:end], "/") {
+		return url[:end] + "/" + url[end:]
 	}
 	return url
 }
 
+// collapseSlashes replaces each run of slashes in the path of url with a single
+// slash. The "//" that follows the scheme, and any query string or fragment, are
+// left untouched.
+func collapseSlashes(url string) string {
+	end := len(url)
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		end = i
+	}
+	start := 0
+	if i := strings.Index(url[:end], "://"); i >= 0 {
+		start = i + len("://")
+	}
+	if !strings.Contains(url[start:end], "//") {
+		return url
+	}
+
+	path := url[start:end]
+	for strings.Contains(path, "//") {
+		path = strings.Replace(path, "//", "/", -1)
+	}
+	return url[:start] + path + url[end:]
+}
+
+// ValidateEndpointURL checks that an endpoint URL, usually taken from a service
+// catalog, is absolute: it must include both a scheme, like "https://", and a
+// host. URLs such as "localhost:5000" would otherwise silently produce
+// malformed requests later on.
+func ValidateEndpointURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("Invalid endpoint URL %q: %s", raw, err)
+	}
+	if u.Scheme == "" || u.Opaque != "" {
+		return fmt.Errorf("Endpoint URL %q is missing a scheme, such as http:// or https://", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("Endpoint URL %q is missing a host", raw)
+	}
+	return nil
+}
+
 // NormalizePathURL is used to convert rawPath to a fqdn, using basePath as
 // a reference in the filesystem, if necessary. basePath is assumed to contain
 // either '.' when first used, or the file:// type fqdn of the parent resource.
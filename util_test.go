@@ -20,17 +20,46 @@ func TestNormalizeURL(t *testing.T) {
 	urls := []string{
 		"NoSlashAtEnd",
 		"SlashAtEnd/",
+		"http://host//v2/",
+		"https://host:8774/v2//tenant///",
+		"http://host/v2//?next=http://other//path/",
+		"http://host//v2#frag//ment",
+		"http://host/v2?x=1",
 	}
 	expected := []string{
 		"NoSlashAtEnd/",
 		"SlashAtEnd/",
+		"http://host/v2/",
+		"https://host:8774/v2/tenant/",
+		"http://host/v2/?next=http://other//path/",
+		"http://host/v2/#frag//ment",
+		"http://host/v2/?x=1",
 	}
 	for i := 0; i < len(expected); i++ {
 		th.CheckEquals(t, expected[i], NormalizeURL(urls[i]))
+		th.CheckEquals(t, expected[i], NormalizeURL(NormalizeURL(urls[i])))
 	}
 
 }
 
+func TestValidateEndpointURL(t *testing.T) {
+	th.CheckNoErr(t, ValidateEndpointURL("https://compute.example.com:8774/v2/"))
+	th.CheckNoErr(t, ValidateEndpointURL("http://127.0.0.1:5000"))
+
+	invalid := []string{
+		"",
+		"localhost:5000",
+		"compute.example.com/v2/",
+		"https:///v2/",
+		"http://[::1",
+	}
+	for _, raw := range invalid {
+		if err := ValidateEndpointURL(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
 func TestNormalizePathURL(t *testing.T) {
 	baseDir, _ := os.Getwd()
 